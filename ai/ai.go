@@ -2,9 +2,15 @@ package ai
 
 import (
 	"image"
+	"time"
 
 	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/ai/transcript"
+	"gitlab.com/256/Underbot/cv/hud"
 	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/rect"
 	"gitlab.com/256/Underbot/sys"
 )
 
@@ -14,6 +20,18 @@ var CurrentState = States[0]
 // Disabled determines whether or not the AI should be turned on
 var Disabled bool
 
+// Entities holds the entities grouped from the recognized objects of the current frame, such as Frisk
+var Entities []object.Entity
+
+// Stats holds the player's stats read from the battle HUD for the current frame, which is set before Handle is called
+var Stats hud.Stats
+
+// Texts holds the text read from the text boxes in the current frame, which is set before Handle is called
+var Texts []ocr.Text
+
+// Transcript records the dialogue read during the session, or is nil if it isn't being recorded
+var Transcript *transcript.Transcript
+
 // Handle is the introduction function to the AI segment. See update() for more details
 func Handle(objects []object.Object, recognizedObjects []object.Object, win sys.Window, img *image.RGBA) error {
 	if !Disabled {
@@ -21,10 +39,41 @@ func Handle(objects []object.Object, recognizedObjects []object.Object, win sys.
 		if err != nil {
 			return errors.Wrap(err, "failed to run the update function")
 		}
+		return nil
+	}
+	// Let go of any keys that were held when the AI was turned off
+	err := releaseKeys(win)
+	if err != nil {
+		return errors.Wrap(err, "failed to release the held keys")
 	}
 	return nil
 }
 
+// FocusRegions gets the regions of interest for the current state from where its anchors were recognized,
+// along with the recognizers that should be used in them. No regions are returned if the state has no focus
+func FocusRegions(recognizedObjects []object.Object) ([]image.Rectangle, []object.RecognizableObject) {
+	focus := CurrentState.focus
+	if Disabled || len(focus.Anchors) == 0 {
+		return nil, nil
+	}
+
+	var regions []image.Rectangle
+	for _, anchor := range matches(focus.Anchors, recognizedObjects) {
+		regions = append(regions, anchor.Bounds.Inset(-focus.Margin))
+	}
+	if len(regions) == 0 {
+		return nil, nil
+	}
+
+	recognizers := append([]object.RecognizableObject{}, CurrentState.signs...)
+	for _, recogObj := range focus.Recognizers {
+		if !contains(recognizers, recogObj) {
+			recognizers = append(recognizers, recogObj)
+		}
+	}
+	return regions, recognizers
+}
+
 // How many frames have happened (resets at 10)
 var frames int
 
@@ -33,7 +82,43 @@ var usedFrames int
 
 // Update runs the appropriate function depending on the current GameState
 func update(objects []object.Object, recognizedObjects []object.Object, win sys.Window, img *image.RGBA) error {
+	Projectiles = nil
+	CurrentReaction = ReactFree
+	CurrentMenu = BattleMenu{Selected: ButtonNone}
+	CurrentSubmenu = nil
+	DodgeTarget = nil
+	Entities = object.Group(recognizedObjects, object.Composites)
+	for _, entity := range Entities {
+		rect.DrawRectangle(img, params.EntityColor, entity.Bounds)
+	}
+
 	CurrentState = identify(recognizedObjects)
+	if CurrentState.Name != "attackGoal" {
+		// An attack whose result wasn't seen before the FIGHT bar closed can't be measured on the next one
+		pendingAttack = nil
+	}
+	if CurrentState.Name != "inBattle" {
+		// Held arrow keys would keep moving the cursor of menus or Frisk
+		lastTarget = nil
+		err := releaseKeys(win)
+		if err != nil {
+			return errors.Wrap(err, "failed to stop dodging")
+		}
+	}
+	if trackBattle(CurrentState, Stats, time.Now()) {
+		identifyEnemies(objects, Texts)
+		mercyShown = updateMercy(Texts)
+	}
+	err := dismissSaved(win)
+	if err != nil {
+		return errors.Wrap(err, "failed to dismiss the saved message")
+	}
+	if Transcript != nil {
+		err := Transcript.Observe(Texts, Entities, CurrentState.Name, time.Now())
+		if err != nil {
+			return errors.Wrap(err, "failed to record the dialogue")
+		}
+	}
 	if CurrentState.times != -1 {
 		if (usedFrames < CurrentState.times) && (frames%2 == 0) {
 			err := CurrentState.updateFun(objects, win, img)
@@ -94,7 +179,7 @@ func matches(objects1 []object.RecognizableObject, objects2 []object.Object) []o
 	// If two identical objects are found within the slices, then add them to the matching slice
 	for _, obj1 := range objects1 {
 		for _, obj2 := range objects2 {
-			if obj1 == obj2.RecogObj.Type {
+			if obj1.Is(obj2.RecogObj.Type) {
 				if !alreadyInSlice(obj2, matching) {
 					matching = append(matching, obj2)
 				}
@@ -107,7 +192,7 @@ func matches(objects1 []object.RecognizableObject, objects2 []object.Object) []o
 
 func alreadyInSlice(a object.Object, list []object.Object) bool {
 	for _, b := range list {
-		if b == a {
+		if b.ID == a.ID {
 			return true
 		}
 	}
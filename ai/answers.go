@@ -0,0 +1,201 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Answer is a rule for which option to pick when the question of a prompt matches it
+type Answer struct {
+	Contains string         // Matches questions that contain this, ignoring case
+	Pattern  *regexp.Regexp // Matches questions that match this. Used instead of Contains when set
+	Pick     string         // The option to pick, which is found by its text, ignoring case
+}
+
+// String describes what the rule matches
+func (a Answer) String() string {
+	if a.Pattern != nil {
+		return fmt.Sprintf("/%s/ -> %s", a.Pattern, a.Pick)
+	}
+	return fmt.Sprintf("%q -> %s", a.Contains, a.Pick)
+}
+
+// Determines if the rule applies to a question
+func (a Answer) matches(question string) bool {
+	if a.Pattern != nil {
+		return a.Pattern.MatchString(question)
+	}
+	return strings.Contains(strings.ToLower(question), strings.ToLower(a.Contains))
+}
+
+// Answers holds the rules for answering prompts, checked in order. The first rule that matches and whose option
+// is shown decides the answer. They are loaded from a file with LoadAnswers
+var Answers []Answer
+
+// The way the rules are written in a file. Patterns are written as strings and compiled when loaded
+type savedAnswers struct {
+	Default string `json:"default"` // The name of the policy for prompts that none of the rules apply to
+	Rules   []struct {
+		Contains string `json:"contains"`
+		Pattern  string `json:"pattern"`
+		Pick     string `json:"pick"`
+	} `json:"rules"`
+}
+
+// LoadAnswers reads the rules for answering prompts, along with the policy for the prompts none of them apply to
+func LoadAnswers(in io.Reader) ([]Answer, Policy, error) {
+	var saved savedAnswers
+	err := json.NewDecoder(in).Decode(&saved)
+	if err != nil {
+		return nil, PolicyKeep, errors.Wrap(err, "failed to decode the answers")
+	}
+	policy, ok := policyNamed(saved.Default)
+	if !ok {
+		return nil, PolicyKeep, errors.New(fmt.Sprintf("there is no policy named %q", saved.Default))
+	}
+	answers := make([]Answer, len(saved.Rules))
+	for i, rule := range saved.Rules {
+		if rule.Pick == "" || (rule.Contains == "") == (rule.Pattern == "") {
+			return nil, PolicyKeep, errors.New(fmt.Sprintf("rule %v needs an option to pick and either contains or a pattern", i))
+		}
+		answers[i] = Answer{Contains: rule.Contains, Pick: rule.Pick}
+		if rule.Pattern != "" {
+			answers[i].Pattern, err = regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, PolicyKeep, errors.Wrap(err, fmt.Sprintf("failed to compile the pattern of rule %v", i))
+			}
+		}
+	}
+	return answers, policy, nil
+}
+
+// Policy is how a prompt is answered when none of the rules apply to it
+type Policy int
+
+// The possible policies
+const (
+	PolicyKeep  Policy = iota // Confirm whatever the game has selected
+	PolicyFirst               // Pick the first option
+	PolicyLast                // Pick the last option
+)
+
+// String gets the name of the policy
+func (p Policy) String() string {
+	switch p {
+	case PolicyFirst:
+		return "first"
+	case PolicyLast:
+		return "last"
+	}
+	return "keep"
+}
+
+// Finds the policy with the name given. An empty name is the policy of keeping what is selected
+func policyNamed(name string) (Policy, bool) {
+	if name == "" {
+		return PolicyKeep, true
+	}
+	for _, policy := range []Policy{PolicyKeep, PolicyFirst, PolicyLast} {
+		if policy.String() == name {
+			return policy, true
+		}
+	}
+	return PolicyKeep, false
+}
+
+// Picks an option of the prompt
+func (p Policy) pick(prompt Prompt) int {
+	switch p {
+	case PolicyFirst:
+		return 0
+	case PolicyLast:
+		return len(prompt.Options) - 1
+	}
+	return prompt.Cursor
+}
+
+// DefaultPolicy is how prompts that none of the rules apply to are answered
+var DefaultPolicy = PolicyKeep
+
+// Decision is a record of how a prompt was answered
+type Decision struct {
+	At       time.Time
+	Question string
+	Options  []string
+	Picked   string
+	Reason   string // The rule or policy that decided the answer
+}
+
+// String describes the decision on a single line
+func (d Decision) String() string {
+	return fmt.Sprintf("%q: picked %q out of %q because of %s", d.Question, d.Picked, d.Options, d.Reason)
+}
+
+// Decisions holds the latest decisions made about prompts during the session, oldest first
+var Decisions []Decision
+
+// KeptDecisions is how many of the latest decisions are kept in Decisions
+var KeptDecisions = 100
+
+// Picks the option to answer a prompt with, logging the decision the first time the prompt is seen
+func choose(prompt Prompt) int {
+	if len(prompt.Options) == 0 {
+		return prompt.Cursor
+	}
+	picked, reason := decide(prompt)
+
+	options := make([]string, len(prompt.Options))
+	for i, option := range prompt.Options {
+		options[i] = option.Text
+	}
+	decision := Decision{
+		At:       time.Now(),
+		Question: prompt.Question,
+		Options:  options,
+		Picked:   options[picked],
+		Reason:   reason,
+	}
+	// The same prompt is answered over several frames while the heart moves to the option
+	if len(Decisions) == 0 || !sameDecision(Decisions[len(Decisions)-1], decision) {
+		Decisions = append(Decisions, decision)
+		if len(Decisions) > KeptDecisions {
+			Decisions = Decisions[len(Decisions)-KeptDecisions:]
+		}
+		fmt.Println("Answering", decision)
+	}
+	return picked
+}
+
+// Decides which option to pick, along with why
+func decide(prompt Prompt) (int, string) {
+	for i, rule := range Answers {
+		if !rule.matches(prompt.Question) {
+			continue
+		}
+		if picked := prompt.find(rule.Pick); picked >= 0 {
+			return picked, fmt.Sprintf("rule %v (%s)", i, rule)
+		}
+	}
+	return DefaultPolicy.pick(prompt), fmt.Sprintf("the %s policy", DefaultPolicy)
+}
+
+// Finds the index of the option whose text contains the text given, ignoring case, or -1 if there isn't one
+func (p Prompt) find(text string) int {
+	for i, option := range p.Options {
+		if strings.Contains(strings.ToLower(option.Text), strings.ToLower(text)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Determines if two decisions were made about the same prompt
+func sameDecision(a, b Decision) bool {
+	return a.Question == b.Question && a.Picked == b.Picked && strings.Join(a.Options, "\n") == strings.Join(b.Options, "\n")
+}
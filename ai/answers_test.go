@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// Makes a prompt with the options given and the cursor on the first one
+func newPrompt(question string, options ...string) Prompt {
+	prompt := Prompt{Question: question}
+	for _, option := range options {
+		prompt.Options = append(prompt.Options, Option{Text: option})
+	}
+	return prompt
+}
+
+func TestDecide(t *testing.T) {
+	file, err := os.Open("../answers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	answers, policy, err := LoadAnswers(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(answers []Answer, policy Policy) {
+		Answers, DefaultPolicy = answers, policy
+	}(Answers, DefaultPolicy)
+	Answers, DefaultPolicy = answers, policy
+
+	tests := []struct {
+		prompt Prompt
+		want   string
+	}{
+		{newPrompt("* Do you want to save your game?", "Yes", "No"), "Yes"},
+		{newPrompt("* Which do you prefer? Cinnamon or butterscotch?", "Cinnamon", "Butterscotch"), "Butterscotch"},
+		{newPrompt("* What is it?", "When can I go home?", "How to exit the RUINS"), "How to exit the RUINS"},
+		// The rule's option isn't shown, so the next rule or the policy decides
+		{newPrompt("* Do you want to save your game?", "Save", "Return"), "Save"},
+		{newPrompt("* Buy a spider donut for 7G?", "Yes", "No"), "Yes"},
+	}
+	for _, test := range tests {
+		picked, reason := decide(test.prompt)
+		if got := test.prompt.Options[picked].Text; got != test.want {
+			t.Errorf("picked %q for %q because of %s, want %q", got, test.prompt.Question, reason, test.want)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	prompt := newPrompt("* Buy a spider donut for 7G?", "Yes", "No")
+	prompt.Cursor = 1
+	for policy, want := range map[Policy]string{PolicyKeep: "No", PolicyFirst: "Yes", PolicyLast: "No"} {
+		if got := prompt.Options[policy.pick(prompt)].Text; got != want {
+			t.Errorf("the %s policy picked %q, want %q", policy, got, want)
+		}
+	}
+}
+
+func TestLoadAnswers(t *testing.T) {
+	bad := []string{
+		`{"default": "random"}`,
+		`{"rules": [{"contains": "save"}]}`,
+		`{"rules": [{"contains": "save", "pattern": "save", "pick": "yes"}]}`,
+		`{"rules": [{"pattern": "(", "pick": "yes"}]}`,
+	}
+	for _, rules := range bad {
+		_, _, err := LoadAnswers(strings.NewReader(rules))
+		if err == nil {
+			t.Errorf("loaded %s", rules)
+		}
+	}
+}
+
+func TestKeptDecisions(t *testing.T) {
+	defer func(kept int) {
+		KeptDecisions = kept
+		Decisions = nil
+	}(KeptDecisions)
+	KeptDecisions = 2
+	Decisions = nil
+
+	for _, question := range []string{"* One?", "* One?", "* Two?", "* Three?"} {
+		choose(newPrompt(question, "Yes", "No"))
+	}
+	if len(Decisions) != 2 || Decisions[0].Question != "* Two?" || Decisions[1].Question != "* Three?" {
+		t.Errorf("kept %v, want the last two", Decisions)
+	}
+}
@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/rect"
+	"gitlab.com/256/Underbot/sys"
+)
+
+// Attack is a press of z on the FIGHT bar, and how close the attackPeg stopped to the center of the attackGoal
+type Attack struct {
+	Pressed  time.Time
+	Expected float64       // How many pixels from the center the peg was expected to stop at
+	Offset   int           // How many pixels from the center the peg stopped at, negative when left of it
+	Accuracy float64       // From 0 when the peg stopped at the edge of the attackGoal to 1 when it stopped at the center
+	Latency  time.Duration // How long the peg took to stop after z was pressed
+}
+
+// String describes the attack on a single line
+func (a Attack) String() string {
+	return fmt.Sprintf("stopped %vpx from the center (expected %.1fpx), %.0f%% accurate, %v latency",
+		a.Offset, a.Expected, a.Accuracy*100, a.Latency)
+}
+
+// Attacks holds every attack made during the session whose result was seen, oldest first
+var Attacks []Attack
+
+// The attack waiting for the peg to stop, or nil if z hasn't been pressed
+var pendingAttack *Attack
+
+// The latest latencies measured between pressing a key and the game reacting to it, oldest first
+var latencies []time.Duration
+
+// KeptLatencies is how many of the latest latencies InputLatency averages, so that it follows changes in the game's speed
+var KeptLatencies = 10
+
+// InputLatency is how long the game takes to react to a key press. This is measured from the attacks made,
+// and params.InputLatency is used until there are any
+func InputLatency() time.Duration {
+	if len(latencies) == 0 {
+		return params.InputLatency
+	}
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	return total / time.Duration(len(latencies))
+}
+
+// Adds a measured latency, forgetting the oldest ones past KeptLatencies
+func addLatency(latency time.Duration) {
+	latencies = append(latencies, latency)
+	if len(latencies) > KeptLatencies {
+		latencies = latencies[len(latencies)-KeptLatencies:]
+	}
+}
+
+// AttackUpdate presses z when the attackPeg will land on the center of the attackGoal, and then measures how close it got
+func AttackUpdate(objects []object.Object, win sys.Window, img *image.RGBA) error {
+	genUpdate()
+	recObjects, err := GetWanted(objects, []object.RecognizableObject{
+		object.RecMap["attackGoal"],
+		object.RecMap["attackPeg"],
+	})
+	if err != nil {
+		// The peg flashes after it stops, so it isn't always found
+		return nil
+	}
+	recMap := Map(recObjects)
+	goal := recMap["attackGoal"][0].Parent.Bounds
+	peg := recMap["attackPeg"][0].Parent
+	center := rect.RectangleCenter(goal).X
+
+	if pendingAttack != nil {
+		finishAttack(peg, goal)
+		return nil
+	}
+
+	if !peg.Motion.Tracked() || peg.Motion.Velocity.X == 0 {
+		// The direction of the peg isn't known yet
+		return nil
+	}
+
+	// How long until the peg reaches the center, from now rather than from when the frame was captured
+	last := peg.Motion.Last()
+	until := untilCenter(float64(rect.RectangleCenter(last.Bounds).X), peg.Motion.Velocity.X, center, time.Since(last.At))
+
+	// Press on the frame that lands the peg closest to the center, or right away if it already went past it
+	latency := InputLatency()
+	if until-latency > params.GameFrame/2 {
+		return nil
+	}
+	err = win.Press("z")
+	if err != nil {
+		return errors.Wrap(err, "failed to press z to attack")
+	}
+	pendingAttack = &Attack{
+		Pressed:  time.Now(),
+		Expected: (until - latency).Seconds() * -peg.Motion.Velocity.X,
+	}
+	return nil
+}
+
+// Works out how long a peg moving at the velocity given, which was at x the time given ago, takes to reach the center.
+// This is negative once it has gone past it
+func untilCenter(x, velocity float64, center int, ago time.Duration) time.Duration {
+	now := x + velocity*ago.Seconds()
+	return time.Duration((float64(center) - now) / velocity * float64(time.Second))
+}
+
+// Works out how long the peg took to stop after z was pressed. The peg stopped somewhere between the sample before
+// the first one that it was stopped in and that one, so the middle of the two is used rather than the later one,
+// which would overestimate the latency by up to a whole capture interval. It returns false if the peg
+// was seen stopped before z was pressed
+func stopLatency(history []object.Sample, stopped int, pressed time.Time) (time.Duration, bool) {
+	if !history[stopped].At.After(pressed) {
+		return 0, false
+	}
+	earliest := pressed
+	if stopped > 0 && history[stopped-1].At.After(pressed) {
+		earliest = history[stopped-1].At
+	}
+	return history[stopped].At.Sub(pressed) - history[stopped].At.Sub(earliest)/2, true
+}
+
+// Works out how accurate an attack stopping the offset given from the center of an attackGoal as wide as given is
+func accuracy(offset, width int) float64 {
+	accuracy := 1 - float64(abs(offset))/(float64(width)/2)
+	if accuracy < 0 {
+		return 0
+	}
+	return accuracy
+}
+
+// Records the pending attack once the peg has stopped moving
+func finishAttack(peg *object.Object, goal image.Rectangle) {
+	if time.Since(pendingAttack.Pressed) > params.AttackTimeout {
+		fmt.Println("The attackPeg never stopped after attacking")
+		pendingAttack = nil
+		return
+	}
+	history := peg.Motion.History
+	if len(history) < 2 || history[len(history)-1].Bounds != history[len(history)-2].Bounds {
+		return
+	}
+
+	// The peg stopped at the first sample in the same place as the last one
+	stopped := len(history) - 1
+	for stopped > 0 && history[stopped-1].Bounds == history[len(history)-1].Bounds {
+		stopped--
+	}
+	attack := *pendingAttack
+	pendingAttack = nil
+	latency, ok := stopLatency(history, stopped, attack.Pressed)
+	if ok {
+		attack.Latency = latency
+		addLatency(latency)
+	}
+	attack.Offset = rect.RectangleCenter(history[stopped].Bounds).X - rect.RectangleCenter(goal).X
+	attack.Accuracy = accuracy(attack.Offset, goal.Dx())
+	Attacks = append(Attacks, attack)
+	fmt.Println("Attacked:", attack)
+}
@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+func TestUntilCenter(t *testing.T) {
+	tests := []struct {
+		x, velocity float64
+		ago         time.Duration
+		want        time.Duration
+	}{
+		{100, 200, 0, time.Second},
+		{100, 200, 250 * time.Millisecond, 750 * time.Millisecond},
+		{500, -200, 0, time.Second},
+		{320, 200, 0, -100 * time.Millisecond}, // Already past the center
+	}
+	for _, test := range tests {
+		got := untilCenter(test.x, test.velocity, 300, test.ago)
+		if got != test.want {
+			t.Errorf("a peg at %v moving %vpx/s seen %v ago reaches 300 in %v, want %v", test.x, test.velocity, test.ago, got, test.want)
+		}
+	}
+}
+
+func TestStopLatency(t *testing.T) {
+	pressed := time.Date(2018, 9, 15, 12, 0, 0, 0, time.UTC)
+	at := func(ms int) object.Sample {
+		return object.Sample{Bounds: image.Rect(0, 0, 10, 10), At: pressed.Add(time.Duration(ms) * time.Millisecond)}
+	}
+	tests := []struct {
+		name    string
+		history []object.Sample
+		stopped int
+		want    time.Duration
+		ok      bool
+	}{
+		// It stopped between 100ms and 150ms, and isn't counted as having taken the full 150ms
+		{"between samples", []object.Sample{at(50), at(100), at(150), at(200)}, 2, 125 * time.Millisecond, true},
+		// It can't have stopped before z was pressed
+		{"sample before pressing", []object.Sample{at(-20), at(40)}, 1, 20 * time.Millisecond, true},
+		{"first sample", []object.Sample{at(60)}, 0, 30 * time.Millisecond, true},
+		{"stopped before pressing", []object.Sample{at(-40), at(-10)}, 1, 0, false},
+	}
+	for _, test := range tests {
+		got, ok := stopLatency(test.history, test.stopped, pressed)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%s: measured %v, %v, want %v, %v", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestAccuracy(t *testing.T) {
+	tests := []struct {
+		offset int
+		want   float64
+	}{
+		{0, 1},
+		{-50, 0.5},
+		{50, 0.5},
+		{100, 0},
+		{150, 0},
+	}
+	for _, test := range tests {
+		got := accuracy(test.offset, 200)
+		if got != test.want {
+			t.Errorf("stopping %vpx from the center is %v accurate, want %v", test.offset, got, test.want)
+		}
+	}
+}
+
+func TestInputLatency(t *testing.T) {
+	defer func() {
+		latencies = nil
+	}()
+
+	latencies = nil
+	if got := InputLatency(); got != params.InputLatency {
+		t.Errorf("assumed %v without any measurements, want %v", got, params.InputLatency)
+	}
+	for i := 0; i < KeptLatencies; i++ {
+		addLatency(200 * time.Millisecond)
+	}
+	for i := 0; i < KeptLatencies; i++ {
+		addLatency(50 * time.Millisecond)
+	}
+	if len(latencies) != KeptLatencies {
+		t.Errorf("kept %v latencies, want %v", len(latencies), KeptLatencies)
+	}
+	if got := InputLatency(); got != 50*time.Millisecond {
+		t.Errorf("estimated %v once the game sped up, want 50ms", got)
+	}
+}
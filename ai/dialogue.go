@@ -0,0 +1,161 @@
+package ai
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/sys"
+)
+
+// Prompt is a choice shown in the dialogue box, such as answering yes or no
+type Prompt struct {
+	Question string // The lines above the options
+	Options  []Option
+	Cursor   int // The index of the option that the heart is next to
+}
+
+// Option is one of the answers of a prompt
+type Option struct {
+	Text   string
+	Bounds image.Rectangle
+}
+
+// String describes the prompt with the option under the cursor in brackets
+func (p Prompt) String() string {
+	options := make([]string, len(p.Options))
+	for i, option := range p.Options {
+		options[i] = option.Text
+		if i == p.Cursor {
+			options[i] = fmt.Sprintf("[%s]", option.Text)
+		}
+	}
+	return fmt.Sprintf("%s %s", p.Question, strings.Join(options, " "))
+}
+
+// CurrentPrompt holds the prompt shown in the current frame, or nil if there isn't one
+var CurrentPrompt *Prompt
+
+// The text last seen in the dialogue box, and when it last changed or a key was pressed
+var (
+	dialogueText    string
+	dialogueChanged time.Time
+)
+
+// Determines if the text in the dialogue box has finished typing out, which is when it hasn't changed for a while
+func typingFinished(text string, at time.Time) bool {
+	if text != dialogueText {
+		dialogueText = text
+		dialogueChanged = at
+		return false
+	}
+	return at.Sub(dialogueChanged) >= params.TypingSettle
+}
+
+// Presses a key in response to the dialogue, and waits for the text to settle again before pressing anything else
+func pressDialogue(win sys.Window, key string) error {
+	dialogueChanged = time.Now()
+	err := win.Press(key)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to press the %s key", key))
+	}
+	return nil
+}
+
+// Gets the text read from the dialogue box
+func dialogueBoxText(texts []ocr.Text) ocr.Text {
+	for _, text := range texts {
+		if text.Box == "dialogueBox" {
+			return text
+		}
+	}
+	return ocr.Text{}
+}
+
+// FindPrompt finds the choice shown in a text box, which is the line that one of the hearts is on.
+// The words on that line are split into options wherever they are far apart
+func FindPrompt(text ocr.Text, hearts []object.Object) (Prompt, bool) {
+	for _, heart := range hearts {
+		center := image.Point{(heart.Bounds.Min.X + heart.Bounds.Max.X) / 2, (heart.Bounds.Min.Y + heart.Bounds.Max.Y) / 2}
+		if !center.In(text.Bounds) {
+			continue
+		}
+		line, ok := lineAt(text, center.Y)
+		if !ok {
+			continue
+		}
+		prompt := Prompt{
+			Question: strings.Join(text.Lines[:line], " "),
+			Options:  options(text.Words, line),
+		}
+		prompt.Cursor = cursorAt(prompt.Options, center.X)
+		return prompt, true
+	}
+	return Prompt{}, false
+}
+
+// Finds the line of the text that is level with the height given
+func lineAt(text ocr.Text, y int) (int, bool) {
+	for _, word := range text.Words {
+		if y >= word.Bounds.Min.Y && y < word.Bounds.Max.Y {
+			return word.Line, true
+		}
+	}
+	return 0, false
+}
+
+// Groups the words on a line into options, starting a new option wherever there is a wide gap
+func options(words []ocr.Word, line int) []Option {
+	var found []Option
+	for _, word := range words {
+		if word.Line != line {
+			continue
+		}
+		last := len(found) - 1
+		if last >= 0 && word.Bounds.Min.X-found[last].Bounds.Max.X < params.OptionGap {
+			found[last].Text += " " + word.Text
+			found[last].Bounds = found[last].Bounds.Union(word.Bounds)
+			continue
+		}
+		found = append(found, Option{Text: word.Text, Bounds: word.Bounds})
+	}
+	return found
+}
+
+// Finds the option that the heart points at, which is the first one to the right of it
+func cursorAt(options []Option, x int) int {
+	for i, option := range options {
+		if option.Bounds.Min.X >= x {
+			return i
+		}
+	}
+	return len(options) - 1
+}
+
+// Gets the recognized hearts among the objects
+func heartsIn(objects []object.Object) []object.Object {
+	var hearts []object.Object
+	for _, obj := range objects {
+		if obj.Recognized && contains(object.Hearts, obj.RecogObj.Type) {
+			hearts = append(hearts, obj)
+		}
+	}
+	return hearts
+}
+
+// Moves the heart towards the chosen option one step at a time, and confirms it once the heart is next to it
+func answer(win sys.Window, prompt Prompt) error {
+	target := choose(prompt)
+	switch {
+	case target < prompt.Cursor:
+		return pressDialogue(win, "left")
+	case target > prompt.Cursor:
+		return pressDialogue(win, "right")
+	}
+	return pressDialogue(win, "z")
+}
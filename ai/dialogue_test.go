@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"image"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+func TestDialogueUpdate(t *testing.T) {
+	defer func(settle time.Duration) {
+		params.TypingSettle = settle
+		Texts = nil
+	}(params.TypingSettle)
+	// The text counts as finished the second frame that it is the same
+	params.TypingSettle = 0
+	dialogueText = ""
+
+	win := newFakeWindow()
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	frames := []struct {
+		text string
+		want string
+	}{
+		{"", ""},                         // The box opened, but no text has been read yet
+		{"", ""},                         // Still nothing to advance
+		{"* Hel", "x"},                   // Typing out, so the rest is shown at once
+		{"* Hello, my child.", "x"},      // Changed since the last frame
+		{"* Hello, my child.", "z"},      // Finished, so it is advanced
+		{"* Welcome to the RUINS!", "x"}, // The next line
+	}
+	for i, frame := range frames {
+		Texts = nil
+		if frame.text != "" {
+			Texts = []ocr.Text{{Box: "dialogueBox", Lines: []string{frame.text}}}
+		}
+		err := DialogueUpdate(nil, win, img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pressed := strings.Join(win.take(), " "); pressed != frame.want {
+			t.Errorf("frame %v with %q pressed %q, want %q", i, frame.text, pressed, frame.want)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package ai
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/mask"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/rect"
+	"gitlab.com/256/Underbot/sys"
+)
+
+// How much the costs of dodging to a position are weighed against each other
+const (
+	hitCost       = 1000.0 // For each frame that the heart would be hit on, more for sooner frames
+	travelCost    = 1.0    // For each pixel the heart has to move
+	centerCost    = 0.5    // For each pixel away from the center of the fightBox, as the edges leave nowhere to go
+	clearanceGain = 2.0    // For each pixel of room from the closest projectile, up to maxClearance
+	maxClearance  = 40.0
+	stickiness    = 30.0 // Taken off for staying near the last target, so the heart doesn't jitter between equal ones
+)
+
+// DodgeTarget is where the heart's bounds are being moved to in the current frame, or nil if it isn't dodging
+var DodgeTarget *image.Rectangle
+
+// The last target dodged to, which is kept between frames
+var lastTarget *image.Rectangle
+
+// The arrow keys being held down
+var heldKeys = map[string]bool{}
+
+// Guards heldKeys, which are released from outside of the AI stage when the program stops
+var keysMutex sync.Mutex
+
+// Whether ReleaseKeys has been called, after which no keys are held anymore
+var keysStopped bool
+
+// When the keys being held last changed
+var keysChanged time.Time
+
+// The latest speeds the heart was measured moving at, oldest first, and the median of them
+var (
+	heartSpeeds []float64
+	heartSpeed  float64
+)
+
+// KeptHeartSpeeds is how many of the latest measurements of the heart's speed HeartSpeed uses
+var KeptHeartSpeeds = 20
+
+// HeartSpeed is how many pixels a second the heart moves at while an arrow key is held. This is measured while dodging,
+// and params.HeartSpeed is used until it has been
+func HeartSpeed() float64 {
+	if len(heartSpeeds) == 0 {
+		return params.HeartSpeed
+	}
+	return heartSpeed
+}
+
+// Adds a measured speed of the heart, forgetting the oldest ones past KeptHeartSpeeds.
+// The median is used, as the heart stopping at the edge of the fightBox partway between frames makes some measurements short
+func addHeartSpeed(speed float64) {
+	heartSpeeds = append(heartSpeeds, speed)
+	if len(heartSpeeds) > KeptHeartSpeeds {
+		heartSpeeds = heartSpeeds[len(heartSpeeds)-KeptHeartSpeeds:]
+	}
+	sorted := append([]float64{}, heartSpeeds...)
+	sort.Float64s(sorted)
+	heartSpeed = sorted[len(sorted)/2]
+}
+
+// Measures how fast the heart moved between its last two samples. This only counts if keys were held the whole time,
+// which starts the input latency after they changed. The heart moves as fast along both axes when moving diagonally,
+// so the faster axis is used. It returns false if there is nothing to measure, such as when the heart is against an edge
+func measureHeartSpeed(history []object.Sample, held bool, changed time.Time, latency time.Duration) (float64, bool) {
+	if !held || len(history) < 2 {
+		return 0, false
+	}
+	from, to := history[len(history)-2], history[len(history)-1]
+	seconds := to.At.Sub(from.At).Seconds()
+	if from.At.Sub(changed) < latency || seconds <= 0 {
+		return 0, false
+	}
+	moved := rect.RectangleCenter(to.Bounds).Sub(rect.RectangleCenter(from.Bounds))
+	speed := math.Max(math.Abs(float64(moved.X)), math.Abs(float64(moved.Y))) / seconds
+	if speed == 0 {
+		return 0, false
+	}
+	return speed, true
+}
+
+// Dodge picks the safest place in the fightBox for the heart to be in the next few frames and holds the arrow keys that move it there.
+// A heart that has to hold still for a blue attack isn't moved, and one that has to keep moving for an orange attack
+// wiggles in place once it gets there
+func Dodge(win sys.Window, heart *object.Object, hitbox mask.Mask, box image.Rectangle, projectiles []Projectile, reaction Reaction) error {
+	DodgeTarget = nil
+	keysMutex.Lock()
+	held, changed := len(heldKeys) > 0, keysChanged
+	keysMutex.Unlock()
+	speed, ok := measureHeartSpeed(heart.Motion.History, held, changed, InputLatency())
+	if ok {
+		addHeartSpeed(speed)
+	}
+
+	if reaction == ReactHoldStill {
+		return releaseKeys(win)
+	}
+
+	offset := safestOffset(heart.Bounds, hitbox, box, projectiles)
+	target := heart.Bounds.Add(offset)
+	DodgeTarget = &target
+	lastTarget = &target
+
+	var keys []string
+	switch {
+	case offset.X > params.DodgeDeadzone:
+		keys = append(keys, "right")
+	case offset.X < -params.DodgeDeadzone:
+		keys = append(keys, "left")
+	}
+	switch {
+	case offset.Y > params.DodgeDeadzone:
+		keys = append(keys, "down")
+	case offset.Y < -params.DodgeDeadzone:
+		keys = append(keys, "up")
+	}
+	if len(keys) == 0 && reaction == ReactKeepMoving {
+		err := releaseKeys(win)
+		if err != nil {
+			return err
+		}
+		return react(win, reaction)
+	}
+	return holdKeys(win, keys)
+}
+
+// Finds how far the heart should move to be the safest, out of a grid of places inside of the box
+func safestOffset(heart image.Rectangle, hitbox mask.Mask, box image.Rectangle, projectiles []Projectile) image.Point {
+	inside := image.Rect(box.Min.X+params.DodgeMargin, box.Min.Y+params.DodgeMargin,
+		box.Max.X-params.DodgeMargin-heart.Dx(), box.Max.Y-params.DodgeMargin-heart.Dy())
+	best := image.Point{}
+	bestCost := dodgeCost(best, heart, hitbox, box, projectiles)
+	for y := inside.Min.Y; y <= inside.Max.Y; y += params.DodgeStep {
+		for x := inside.Min.X; x <= inside.Max.X; x += params.DodgeStep {
+			offset := image.Pt(x, y).Sub(heart.Min)
+			cost := dodgeCost(offset, heart, hitbox, box, projectiles)
+			if cost < bestCost {
+				best = offset
+				bestCost = cost
+			}
+		}
+	}
+	return best
+}
+
+// Gets how bad it would be for the heart to move by the offset given. The heart is assumed to move in a straight line
+// at HeartSpeed, and is checked against every projectile in each of the frames predicted on its way there
+func dodgeCost(offset image.Point, heart image.Rectangle, hitbox mask.Mask, box image.Rectangle, projectiles []Projectile) float64 {
+	distance := math.Hypot(float64(offset.X), float64(offset.Y))
+	perFrame := HeartSpeed() * params.GameFrame.Seconds()
+	target := heart.Add(offset)
+	center := rect.RectangleCenter(target)
+
+	cost := travelCost*distance + centerCost*pointDistance(center, rect.RectangleCenter(box))
+	if lastTarget != nil && pointDistance(target.Min, lastTarget.Min) <= float64(params.DodgeStep) {
+		cost -= stickiness
+	}
+
+	clearance := maxClearance
+	for ahead := 1; ahead <= params.PredictFrames; ahead++ {
+		travelled := math.Min(distance, perFrame*float64(ahead))
+		moving := travelled < distance
+		position := image.Point{}
+		if distance > 0 {
+			position = image.Pt(int(float64(offset.X)*travelled/distance), int(float64(offset.Y)*travelled/distance))
+		}
+		moved := hitbox.Translate(position)
+		for _, proj := range projectiles {
+			// Blue attacks can only hurt the heart while it moves, and orange ones only once it stops
+			if (proj.Color == AttackBlue && !moving) || (proj.Color == AttackOrange && moving) {
+				continue
+			}
+			if Collides(moved, proj, ahead) {
+				cost += hitCost * float64(params.PredictFrames+2-ahead)
+			}
+		}
+	}
+	for _, proj := range projectiles {
+		bounds := proj.Bounds
+		if len(proj.Predicted) > 0 {
+			bounds = proj.Predicted[len(proj.Predicted)-1]
+		}
+		clearance = math.Min(clearance, gap(target, bounds))
+	}
+	return cost - clearanceGain*clearance
+}
+
+// Gets the distance between two points
+func pointDistance(a, b image.Point) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+// Gets how many pixels apart two rectangles are, which is 0 if they overlap
+func gap(a, b image.Rectangle) float64 {
+	dx := math.Max(0, math.Max(float64(b.Min.X-a.Max.X), float64(a.Min.X-b.Max.X)))
+	dy := math.Max(0, math.Max(float64(b.Min.Y-a.Max.Y), float64(a.Min.Y-b.Max.Y)))
+	return math.Hypot(dx, dy)
+}
+
+// Holds down the keys given, releasing any other keys that were held
+func holdKeys(win sys.Window, keys []string) error {
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+	if keysStopped {
+		keys = nil
+	}
+	wanted := map[string]bool{}
+	for _, key := range keys {
+		wanted[key] = true
+	}
+	for key := range heldKeys {
+		if wanted[key] {
+			continue
+		}
+		err := win.Release(key)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to release %s", key))
+		}
+		delete(heldKeys, key)
+		keysChanged = time.Now()
+	}
+	for key := range wanted {
+		if heldKeys[key] {
+			continue
+		}
+		err := win.Hold(key)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to hold %s", key))
+		}
+		heldKeys[key] = true
+		keysChanged = time.Now()
+	}
+	return nil
+}
+
+// Releases every key that is held down
+func releaseKeys(win sys.Window) error {
+	return holdKeys(win, nil)
+}
+
+// ReleaseKeys lets go of every key that the AI is holding down, and keeps it from holding any more.
+// This is for when the program stops, as held keys would otherwise stay down in the game
+func ReleaseKeys(win sys.Window) error {
+	keysMutex.Lock()
+	keysStopped = true
+	keysMutex.Unlock()
+	return releaseKeys(win)
+}
+
+// Draws where the heart is dodging to
+func drawDodge(img *image.RGBA) {
+	if DodgeTarget != nil {
+		rect.DrawRectangle(img, params.DodgeColor, *DodgeTarget)
+	}
+}
+
+// Finds the bounds of the fightBox
+func fightBox(objects []object.Object) (image.Rectangle, bool) {
+	for _, obj := range objects {
+		if obj.Recognized && obj.RecogObj.Type.Is(object.RecMap["fightBox"]) {
+			return obj.Bounds, true
+		}
+	}
+	return image.Rectangle{}, false
+}
@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"gitlab.com/256/Underbot/cv/mask"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+// The fightBox and the heart in the middle of it that the dodging tests use
+var (
+	dodgeBox   = image.Rect(200, 200, 400, 400)
+	dodgeHeart = image.Rect(292, 292, 308, 308)
+)
+
+// Makes a projectile covering the bounds given that moves by the step given every frame
+func moving(bounds image.Rectangle, step image.Point, color AttackColor) Projectile {
+	proj := Projectile{Bounds: bounds, Mask: mask.FromRect(bounds), Color: color}
+	for ahead := 1; ahead <= params.PredictFrames; ahead++ {
+		proj.Predicted = append(proj.Predicted, bounds.Add(step.Mul(ahead)))
+	}
+	return proj
+}
+
+func TestGap(t *testing.T) {
+	tests := []struct {
+		a, b image.Rectangle
+		want float64
+	}{
+		{image.Rect(0, 0, 10, 10), image.Rect(5, 5, 15, 15), 0},
+		{image.Rect(0, 0, 10, 10), image.Rect(10, 0, 20, 10), 0},
+		{image.Rect(0, 0, 10, 10), image.Rect(17, 2, 20, 8), 7},
+		{image.Rect(0, 20, 10, 30), image.Rect(0, 0, 10, 10), 10},
+		{image.Rect(0, 0, 10, 10), image.Rect(13, 14, 20, 20), 5},
+	}
+	for _, test := range tests {
+		if got := gap(test.a, test.b); got != test.want {
+			t.Errorf("%v and %v are %v apart, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestDodgeCost(t *testing.T) {
+	defer func() {
+		lastTarget = nil
+	}()
+	lastTarget = nil
+	hitbox := mask.FromRect(dodgeHeart)
+
+	// Staying in the path of a projectile costs more than moving out of it
+	bullet := moving(image.Rect(230, 294, 242, 306), image.Pt(15, 0), AttackWhite)
+	stay := dodgeCost(image.Point{}, dodgeHeart, hitbox, dodgeBox, []Projectile{bullet})
+	away := dodgeCost(image.Pt(0, 40), dodgeHeart, hitbox, dodgeBox, []Projectile{bullet})
+	if stay <= away {
+		t.Errorf("staying in the path of a bullet costs %v, which isn't more than the %v of moving out of it", stay, away)
+	}
+
+	// Blue attacks only hurt the heart while it moves
+	blue := moving(dodgeHeart.Inset(2), image.Point{}, AttackBlue)
+	still := dodgeCost(image.Point{}, dodgeHeart, hitbox, dodgeBox, []Projectile{blue})
+	moved := dodgeCost(image.Pt(30, 0), dodgeHeart, hitbox, dodgeBox, []Projectile{blue})
+	if still >= hitCost || moved < hitCost {
+		t.Errorf("holding still in a blue attack costs %v and moving through it costs %v", still, moved)
+	}
+}
+
+func TestSafestOffset(t *testing.T) {
+	defer func() {
+		lastTarget = nil
+	}()
+	lastTarget = nil
+	hitbox := mask.FromRect(dodgeHeart)
+
+	// Without anything to dodge, the heart stays in the middle of the box
+	offset := safestOffset(dodgeHeart, hitbox, dodgeBox, nil)
+	if offset != (image.Point{}) {
+		t.Errorf("moved by %v without anything to dodge", offset)
+	}
+
+	// A bullet flying along the heart's row makes it move off the row
+	bullet := moving(image.Rect(230, 294, 242, 306), image.Pt(15, 0), AttackWhite)
+	offset = safestOffset(dodgeHeart, hitbox, dodgeBox, []Projectile{bullet})
+	target := dodgeHeart.Add(offset)
+	if target.Min.Y < bullet.Bounds.Max.Y && target.Max.Y > bullet.Bounds.Min.Y {
+		t.Errorf("moved by %v to %v, which is still in the path of the bullet along %v", offset, target, bullet.Bounds)
+	}
+	if !target.In(dodgeBox) {
+		t.Errorf("moved by %v to %v, which is outside of the fightBox", offset, target)
+	}
+}
+
+func TestMeasureHeartSpeed(t *testing.T) {
+	start := time.Date(2018, 9, 15, 12, 0, 0, 0, time.UTC)
+	at := func(ms, x, y int) object.Sample {
+		return object.Sample{Bounds: image.Rect(x, y, x+16, y+16), At: start.Add(time.Duration(ms) * time.Millisecond)}
+	}
+	latency := 50 * time.Millisecond
+	tests := []struct {
+		name    string
+		history []object.Sample
+		held    bool
+		want    float64
+		ok      bool
+	}{
+		{"moving right", []object.Sample{at(100, 0, 0), at(150, 6, 0)}, true, 120, true},
+		{"moving diagonally", []object.Sample{at(100, 0, 0), at(150, -6, 6)}, true, 120, true},
+		{"nothing held", []object.Sample{at(100, 0, 0), at(150, 6, 0)}, false, 0, false},
+		{"against an edge", []object.Sample{at(100, 0, 0), at(150, 0, 0)}, true, 0, false},
+		{"keys just changed", []object.Sample{at(20, 0, 0), at(70, 6, 0)}, true, 0, false},
+	}
+	for _, test := range tests {
+		got, ok := measureHeartSpeed(test.history, test.held, start, latency)
+		if got != test.want || ok != test.ok {
+			t.Errorf("%s: measured %v, %v, want %v, %v", test.name, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestHeartSpeed(t *testing.T) {
+	defer func() {
+		heartSpeeds = nil
+	}()
+
+	heartSpeeds = nil
+	if got := HeartSpeed(); got != params.HeartSpeed {
+		t.Errorf("assumed %v without any measurements, want %v", got, params.HeartSpeed)
+	}
+	// Measurements cut short by an edge don't drag the speed down
+	for _, speed := range []float64{150, 150, 40, 150, 60} {
+		addHeartSpeed(speed)
+	}
+	if got := HeartSpeed(); got != 150 {
+		t.Errorf("estimated %v, want 150", got)
+	}
+	for i := 0; i < KeptHeartSpeeds; i++ {
+		addHeartSpeed(90)
+	}
+	if len(heartSpeeds) != KeptHeartSpeeds || HeartSpeed() != 90 {
+		t.Errorf("kept %v speeds estimating %v, want %v estimating 90", len(heartSpeeds), HeartSpeed(), KeptHeartSpeeds)
+	}
+}
+
+func TestReleaseKeys(t *testing.T) {
+	defer func() {
+		heldKeys = map[string]bool{}
+		keysStopped = false
+	}()
+	win := newFakeWindow()
+
+	err := holdKeys(win, []string{"up", "left"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ReleaseKeys(win)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(win.held) != 0 || len(heldKeys) != 0 {
+		t.Errorf("still holding %v once stopped", win.held)
+	}
+
+	// Nothing is held after stopping
+	err = holdKeys(win, []string{"down"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(win.held) != 0 {
+		t.Errorf("held %v after stopping", win.held)
+	}
+}
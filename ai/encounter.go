@@ -0,0 +1,267 @@
+package ai
+
+import (
+	"encoding/json"
+	"image"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/hud"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/phash"
+)
+
+// Encounter is the battle currently being fought
+type Encounter struct {
+	Started  time.Time
+	Enemies  []Enemy // From left to right
+	CanSpare bool    // Whether Spare was shown in yellow the last time the MERCY menu was open
+	// Whether choosing ITEM didn't open the list of items, which happens once there are none left.
+	// Items can be picked up between battles, so this is only remembered for the battle
+	OutOfItems bool
+	Stuck      []Button // The buttons that the cursor couldn't get to or choose in this battle
+}
+
+// Enemy is one of the monsters being fought
+type Enemy struct {
+	Name      string          // Empty if it isn't known yet
+	Bounds    image.Rectangle // Where its sprite is, or empty if it was only seen in the target list
+	Hash      uint64          // The perceptual hash of its sprite, or 0 if it wasn't seen
+	Spareable bool            // Whether its name was shown in yellow the last time it was listed
+}
+
+// Names gets the names of the enemies that are known
+func (e Encounter) Names() []string {
+	var names []string
+	for _, enemy := range e.Enemies {
+		if enemy.Name != "" {
+			names = append(names, enemy.Name)
+		}
+	}
+	return names
+}
+
+// Has determines if one of the enemies has the name given, ignoring case
+func (e Encounter) Has(name string) bool {
+	for _, enemy := range e.Enemies {
+		if strings.EqualFold(enemy.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// EnemySprite is the perceptual hash of a known enemy's sprite
+type EnemySprite struct {
+	Name string `json:"name"`
+	Hash uint64 `json:"hash,string"`
+}
+
+// EnemySprites holds the sprites that enemies are identified by before their names are read.
+// Sprites are added whenever the enemies' names are read from the target list while their sprites are on screen,
+// and they are saved with SaveSprites so that they are known from the start of the next session
+var EnemySprites []EnemySprite
+
+// Guards EnemySprites, which are saved from outside of the AI's goroutine
+var spritesMutex sync.Mutex
+
+// LoadSprites reads the sprites saved by SaveSprites, adding them to the ones already known
+func LoadSprites(in io.Reader) error {
+	var sprites []EnemySprite
+	err := json.NewDecoder(in).Decode(&sprites)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode the sprites")
+	}
+	for _, sprite := range sprites {
+		learnSprite(sprite.Name, sprite.Hash)
+	}
+	return nil
+}
+
+// SaveSprites writes every known sprite
+func SaveSprites(out io.Writer) error {
+	spritesMutex.Lock()
+	encoded, err := json.MarshalIndent(EnemySprites, "", "\t")
+	spritesMutex.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the sprites")
+	}
+	_, err = out.Write(encoded)
+	if err != nil {
+		return errors.Wrap(err, "failed to write the sprites")
+	}
+	return nil
+}
+
+// EnemyNames holds the names of the enemies as they are written in the target list
+var EnemyNames = []string{
+	"Dummy", "Froggit", "Whimsun", "Moldsmal", "Loox", "Vegetoid", "Migosp", "Napstablook", "Toriel",
+	"Snowdrake", "Ice Cap", "Gyftrot", "Doggo", "Lesser Dog", "Greater Dog", "Dogamy", "Dogaressa", "Jerry",
+	"Chilldrake", "Papyrus", "Aaron", "Woshua", "Moldbygg", "Shyren", "Temmie", "Mad Dummy", "Undyne",
+	"Vulkin", "Tsunderplane", "Pyrope", "Muffet", "Mettaton", "RG 01", "RG 02", "So Sorry", "Glyde",
+	"Final Froggit", "Whimsalot", "Astigmatism", "Madjick", "Knight Knight", "Asgore",
+	"Lemon Bread", "Reaper Bird", "Memoryhead", "Endogeny",
+}
+
+// PeacefulActs holds the act that makes each enemy spareable, for the enemies where one is known
+var PeacefulActs = map[string]string{
+	"Dummy":       "Talk",
+	"Froggit":     "Compliment",
+	"Whimsun":     "Console",
+	"Moldsmal":    "Imitate",
+	"Loox":        "Don't Pick On",
+	"Vegetoid":    "Dinner",
+	"Napstablook": "Cheer",
+	"Snowdrake":   "Laugh",
+	"Ice Cap":     "Ignore",
+	"Doggo":       "Pet",
+	"Lesser Dog":  "Pet",
+}
+
+// Finds an enemy that can't be spared yet and has a peaceful act, returning its name and the act
+func (e Encounter) peacefulAct() (string, string, bool) {
+	for _, enemy := range e.Enemies {
+		if act, ok := PeacefulActs[enemy.Name]; ok && !enemy.Spareable {
+			return enemy.Name, act, true
+		}
+	}
+	return "", "", false
+}
+
+// Recognizers whose objects are enemies, along with the enemy's name
+var enemyRecognizers = map[string]string{
+	"dummy": "Dummy",
+}
+
+// CurrentEncounter holds the battle currently being fought, which is reset whenever a new battle starts
+var CurrentEncounter Encounter
+
+// Identifies the enemies of the current battle from their sprites and the names in the target list
+func identifyEnemies(objects []object.Object, texts []ocr.Text) {
+	sprites := enemySprites(objects)
+	names := targetNames(texts)
+
+	var enemies []Enemy
+	switch {
+	case len(names) > 0 && len(names) == len(sprites):
+		// The target list is in the same order as the sprites, so they can be paired up and remembered
+		for i, sprite := range sprites {
+			learnSprite(names[i], sprite.Hash)
+			enemies = append(enemies, Enemy{Name: names[i], Bounds: sprite.Bounds, Hash: sprite.Hash})
+		}
+	case len(sprites) > 0:
+		for _, sprite := range sprites {
+			enemies = append(enemies, Enemy{Name: spriteName(sprite), Bounds: sprite.Bounds, Hash: sprite.Hash})
+		}
+	default:
+		for _, name := range names {
+			enemies = append(enemies, Enemy{Name: name})
+		}
+	}
+
+	// Sprites that can't be seen (such as while the CV is focused on the fightBox) don't forget what is known
+	if len(enemies) > 0 && len(Encounter{Enemies: enemies}.Names()) >= len(CurrentEncounter.Names()) {
+		// Whether an enemy can be spared is only seen in the menus, so it is kept for the enemy in the same place
+		for i := range enemies {
+			if i < len(CurrentEncounter.Enemies) && CurrentEncounter.Enemies[i].Name == enemies[i].Name {
+				enemies[i].Spareable = CurrentEncounter.Enemies[i].Spareable
+			}
+		}
+		CurrentEncounter.Enemies = enemies
+	}
+}
+
+// Finds the objects that look like enemy sprites, which are the large objects above the box that aren't inside of
+// anything, or objects recognized as an enemy. They are sorted from left to right
+func enemySprites(objects []object.Object) []object.Object {
+	top := hud.Locate(objects).Min.Y
+	for _, obj := range objects {
+		if obj.Recognized && (obj.RecogObj.Type.Is(object.RecMap["narratorBox"]) ||
+			obj.RecogObj.Type.Is(object.RecMap["fightBox"])) && obj.Bounds.Min.Y < top {
+			top = obj.Bounds.Min.Y
+		}
+	}
+
+	var sprites []object.Object
+	for _, obj := range objects {
+		_, enemy := enemyRecognizers[obj.RecogObj.Type.Name]
+		if obj.Recognized && enemy {
+			sprites = append(sprites, obj)
+			continue
+		}
+		if obj.Recognized || obj.Parent != nil || obj.Hash == 0 || obj.Bounds.Max.Y > top {
+			continue
+		}
+		sprites = append(sprites, obj)
+	}
+	sort.Slice(sprites, func(i, j int) bool {
+		return sprites[i].Bounds.Min.X < sprites[j].Bounds.Min.X
+	})
+	return sprites
+}
+
+// Gets the name of the enemy a sprite belongs to, or an empty string if the sprite isn't known
+func spriteName(sprite object.Object) string {
+	if sprite.Recognized {
+		return enemyRecognizers[sprite.RecogObj.Type.Name]
+	}
+	spritesMutex.Lock()
+	defer spritesMutex.Unlock()
+	best := ""
+	bestDistance := params.HashMaxDistance + 1
+	for _, known := range EnemySprites {
+		if distance := phash.Distance(known.Hash, sprite.Hash); distance < bestDistance {
+			best = known.Name
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// Remembers what an enemy's sprite looks like, unless a close enough sprite is already known for it
+func learnSprite(name string, hash uint64) {
+	if hash == 0 {
+		return
+	}
+	spritesMutex.Lock()
+	defer spritesMutex.Unlock()
+	for _, known := range EnemySprites {
+		if known.Name == name && phash.Distance(known.Hash, hash) <= params.HashMaxDistance {
+			return
+		}
+	}
+	EnemySprites = append(EnemySprites, EnemySprite{Name: name, Hash: hash})
+}
+
+// Reads the enemy names from the target list in the narratorBox.
+// Only lines that are nothing but an enemy's name count, so flavor text that mentions an enemy is left out
+func targetNames(texts []ocr.Text) []string {
+	var names []string
+	for _, text := range texts {
+		if text.Box != "narratorBox" {
+			continue
+		}
+		for _, line := range text.Lines {
+			if name, ok := enemyName(strings.TrimSpace(strings.TrimPrefix(line, "*"))); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Finds the enemy name that a line of text is, allowing for a few characters to be misread
+func enemyName(line string) (string, bool) {
+	for _, name := range EnemyNames {
+		if ocr.Misreads(strings.ToLower(line), strings.ToLower(name)) <= len(name)/4 {
+			return name, true
+		}
+	}
+	return "", false
+}
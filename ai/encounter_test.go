@@ -0,0 +1,44 @@
+package ai
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/256/Underbot/cv/object"
+)
+
+func TestSprites(t *testing.T) {
+	defer func() {
+		EnemySprites = nil
+	}()
+	EnemySprites = nil
+
+	// A sprite close to a known one isn't learned again
+	learnSprite("Froggit", 0xf0f0f0f0f0f0f0f0)
+	learnSprite("Froggit", 0xf0f0f0f0f0f0f0f1)
+	learnSprite("Whimsun", 0x0f0f0f0f0f0f0f0f)
+	if len(EnemySprites) != 2 {
+		t.Fatalf("learned %v sprites, want 2", len(EnemySprites))
+	}
+
+	var saved bytes.Buffer
+	err := SaveSprites(&saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	EnemySprites = nil
+	err = LoadSprites(&saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(EnemySprites) != 2 || EnemySprites[0].Hash != 0xf0f0f0f0f0f0f0f0 {
+		t.Errorf("loaded %v, want the sprites saved", EnemySprites)
+	}
+
+	if name := spriteName(object.Object{Hash: 0xf0f0f0f0f0f0f0f3}); name != "Froggit" {
+		t.Errorf("named a sprite close to Froggit's %q", name)
+	}
+	if name := spriteName(object.Object{Hash: 0xffff00000000ffff}); name != "" {
+		t.Errorf("named a sprite that isn't known %q", name)
+	}
+}
@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"time"
+
+	"gitlab.com/256/Underbot/cv/hud"
+	"gitlab.com/256/Underbot/cv/mask"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+// HitsTaken counts the hits the heart has taken in the current battle
+var HitsTaken int
+
+// When the last hit was counted, as the heart can't be hurt again until params.HitCooldown has passed
+var lastHit time.Time
+
+// Whether the heart was seen in the last frame of the battle, so that it vanishing can be noticed
+var heartSeen bool
+
+// When the heart vanished from the fightBox, or zero while it is visible
+var vanishedAt time.Time
+
+// How many times in a row the heart has blinked, and when the first of those blinks started
+var (
+	blinks     int
+	blinkStart time.Time
+)
+
+// The states that are part of a battle. Leaving all of them ends the battle
+var battleStates = []string{"battleMenu", "inBattle", "attackGoal"}
+
+// Whether the last state was part of a battle
+var wasInBattle bool
+
+// The HP last read from the HUD in the current battle, or 0 if it hasn't been read yet
+var lastHP int
+
+// HeartHitbox gets the pixels of the heart that attacks can hurt, which are fewer than the heart's sprite covers
+func HeartHitbox(heart *object.Object) mask.Mask {
+	hitbox := heart.Mask().Erode(params.HeartHitboxInset)
+	if hitbox.Empty() {
+		// The heart was too small or oddly shaped to shrink, so the whole sprite has to do
+		return heart.Mask()
+	}
+	return hitbox
+}
+
+// Collides determines if a projectile will cover any of the hitbox's pixels the number of game frames ahead given,
+// where 0 is the current frame. Frames further ahead than the predictions are never collisions
+func Collides(hitbox mask.Mask, proj Projectile, ahead int) bool {
+	if ahead == 0 {
+		return hitbox.Collides(proj.Mask)
+	}
+	if ahead > len(proj.Predicted) {
+		return false
+	}
+	offset := proj.Predicted[ahead-1].Min.Sub(proj.Bounds.Min)
+	return hitbox.Collides(proj.Mask.Translate(offset))
+}
+
+// Resets the hit count and the encounter whenever a new battle starts, and counts drops in HP as hits during one.
+// It returns whether the state is part of a battle
+func trackBattle(state State, stats hud.Stats, at time.Time) bool {
+	inBattle := false
+	for _, name := range battleStates {
+		if state.Name == name {
+			inBattle = true
+		}
+	}
+	if inBattle && !wasInBattle {
+		HitsTaken = 0
+		lastHit = time.Time{}
+		heartSeen = false
+		vanishedAt = time.Time{}
+		blinks = 0
+		lastHP = 0
+		CurrentEncounter = Encounter{Started: at}
+	}
+	if !inBattle && wasInBattle {
+		// Options for the submenus of a battle that is over won't ever be shown
+		Selections = nil
+	}
+	wasInBattle = inBattle
+
+	if inBattle && stats.Valid {
+		if lastHP > 0 && stats.HP < lastHP {
+			registerHit(at)
+		}
+		lastHP = stats.HP
+	}
+	return inBattle
+}
+
+// Counts a hit unless the heart is still recovering from the last one
+func registerHit(at time.Time) {
+	if at.Sub(lastHit) < params.HitCooldown {
+		return
+	}
+	HitsTaken++
+	lastHit = at
+}
+
+// Checks whether the heart is being hit in the current frame.
+// The heart is hit when a projectile that hurts it overlaps its hitbox, or when it blinks after a hit
+// the bot didn't see happen. While the HUD can be read, drops in HP are counted instead, so nothing is counted here
+func detectHit(heart *object.Object, hitbox mask.Mask, projectiles []Projectile, hudValid bool, at time.Time) {
+	heartSeen = true
+	reappeared := !vanishedAt.IsZero() && at.Sub(vanishedAt) <= params.FlashMaxGap
+	if !vanishedAt.IsZero() {
+		blinked(reappeared, hudValid)
+		vanishedAt = time.Time{}
+	}
+	if hudValid {
+		return
+	}
+
+	moving := heartMoving(heart)
+	for _, proj := range projectiles {
+		if !Collides(hitbox, proj, 0) {
+			continue
+		}
+		// Blue attacks only hurt a moving heart, and orange attacks only hurt a still one
+		if (proj.Color == AttackBlue && !moving) || (proj.Color == AttackOrange && moving) {
+			continue
+		}
+		registerHit(at)
+		return
+	}
+}
+
+// Notes when the heart vanishes from the fightBox, as it blinking on and off is it flashing after a hit.
+// Vanishing alone isn't a hit, as it also happens when an attack starts or ends and when a projectile merges with it
+func detectFlash(objects []object.Object, at time.Time) {
+	if !heartSeen {
+		return
+	}
+	heartSeen = false
+	if _, ok := fightBox(objects); ok {
+		vanishedAt = at
+	}
+}
+
+// Counts a blink of the heart, which is it vanishing and then coming back quickly.
+// Enough blinks in a row are the heart flashing after a hit, which counts as one unless the HUD is counting hits instead
+func blinked(quickly bool, hudValid bool) {
+	if !quickly || vanishedAt.Sub(blinkStart) > params.HitCooldown {
+		blinks = 0
+	}
+	if !quickly {
+		return
+	}
+	if blinks == 0 {
+		blinkStart = vanishedAt
+	}
+	blinks++
+	if blinks >= params.FlashBlinks {
+		if !hudValid {
+			registerHit(blinkStart)
+		}
+		blinks = 0
+	}
+}
+
+// Determines if the heart moved since the last frame
+func heartMoving(heart *object.Object) bool {
+	history := heart.Motion.History
+	if len(history) < 2 {
+		return false
+	}
+	return history[len(history)-1].Bounds.Min != history[len(history)-2].Bounds.Min
+}
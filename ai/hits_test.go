@@ -0,0 +1,44 @@
+package ai
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/256/Underbot/cv/mask"
+	"gitlab.com/256/Underbot/cv/object"
+)
+
+// TestFlash checks that only the heart blinking on and off counts as a hit, and not it vanishing once or for long
+func TestFlash(t *testing.T) {
+	box := []object.Object{{Recognized: true, RecogObj: object.RecognizedObject{Type: object.RecMap["fightBox"]}}}
+	heart := &object.Object{}
+	start := time.Date(2018, 7, 31, 0, 0, 0, 0, time.UTC)
+	frame := 33 * time.Millisecond
+
+	cases := []struct {
+		name     string
+		visible  string // Whether the heart is seen in each frame, from '#' for seen and '.' for not
+		hudValid bool
+		want     int
+	}{
+		{"blinking", "##..##..##..##", false, 1},
+		{"vanishing once", "####..########", false, 0},
+		{"attack ending", "####..........", false, 0},
+		{"blinking with the HUD read", "##..##..##..##", true, 0},
+		{"blinking after two hits", "#..#..#" + "##############################" + "..#..#", false, 2},
+	}
+	for _, c := range cases {
+		HitsTaken, lastHit, heartSeen, vanishedAt, blinks = 0, time.Time{}, false, time.Time{}, 0
+		for i, seen := range c.visible {
+			at := start.Add(time.Duration(i) * frame)
+			if seen == '#' {
+				detectHit(heart, mask.Mask{}, nil, c.hudValid, at)
+			} else {
+				detectFlash(box, at)
+			}
+		}
+		if HitsTaken != c.want {
+			t.Errorf("%s: counted %v hits, want %v", c.name, HitsTaken, c.want)
+		}
+	}
+}
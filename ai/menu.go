@@ -0,0 +1,156 @@
+package ai
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"strings"
+
+	"gitlab.com/256/Underbot/cv/hud"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/rect"
+)
+
+// Button is one of the four buttons of the battle menu
+type Button int
+
+// The buttons, in the order they appear from left to right
+const (
+	ButtonNone  Button = iota - 1 // Used when no button is highlighted
+	ButtonFight                   // FIGHT
+	ButtonAct                     // ACT
+	ButtonItem                    // ITEM
+	ButtonMercy                   // MERCY
+)
+
+// String gets the label of the button
+func (b Button) String() string {
+	switch b {
+	case ButtonFight:
+		return "FIGHT"
+	case ButtonAct:
+		return "ACT"
+	case ButtonItem:
+		return "ITEM"
+	case ButtonMercy:
+		return "MERCY"
+	}
+	return "none"
+}
+
+// BattleMenu is the battle menu's buttons as seen in the current frame
+type BattleMenu struct {
+	Bounds   [4]image.Rectangle // Where each button is, indexed by Button. Empty for the buttons that weren't found
+	Selected Button             // The highlighted button, which is yellow instead of orange and has the heart on it
+}
+
+// Found determines if a button was seen
+func (m BattleMenu) Found(button Button) bool {
+	return button >= ButtonFight && button <= ButtonMercy && !m.Bounds[button].Empty()
+}
+
+// String describes the menu with the highlighted button in brackets
+func (m BattleMenu) String() string {
+	var labels []string
+	for button := ButtonFight; button <= ButtonMercy; button++ {
+		if !m.Found(button) {
+			continue
+		}
+		label := button.String()
+		if button == m.Selected {
+			label = fmt.Sprintf("[%s]", label)
+		}
+		labels = append(labels, label)
+	}
+	return strings.Join(labels, " ")
+}
+
+// CurrentMenu holds the battle menu seen in the current frame
+var CurrentMenu BattleMenu
+
+// ReadBattleMenu identifies the battle buttons among the objects. Each button is labelled by the text read from it
+// when that is one of the labels. Otherwise, the buttons are in order from left to right when all four were found,
+// or else labelled by which quarter of the screen their center is in, as they split its width into four.
+// It returns false if none of the buttons were found
+func ReadBattleMenu(objects []object.Object, texts []ocr.Text, screen image.Rectangle) (BattleMenu, bool) {
+	menu := BattleMenu{Selected: ButtonNone}
+	var hearts []image.Point
+	var buttons []object.Object
+	for _, obj := range objects {
+		if obj.Recognized && contains(object.Hearts, obj.RecogObj.Type) {
+			hearts = append(hearts, rect.RectangleCenter(obj.Bounds))
+		}
+		if obj.Recognized && obj.RecogObj.Type.Is(object.RecMap["battleOption"]) {
+			buttons = append(buttons, obj)
+		}
+	}
+	sort.Slice(buttons, func(i, j int) bool {
+		return buttons[i].Bounds.Min.X < buttons[j].Bounds.Min.X
+	})
+
+	found := false
+	for i, obj := range buttons {
+		button, ok := buttonLabel(obj.Bounds, texts)
+		switch {
+		case ok:
+		case len(buttons) == len(menu.Bounds):
+			button = Button(i)
+		case screen.Dx() > 0:
+			button = Button((rect.RectangleCenter(obj.Bounds).X - screen.Min.X) * 4 / screen.Dx())
+		}
+		if button < ButtonFight || button > ButtonMercy {
+			continue
+		}
+		menu.Bounds[button] = obj.Bounds
+		found = true
+		if isHighlighted(obj, hearts) {
+			menu.Selected = button
+		}
+	}
+	return menu, found
+}
+
+// Finds which button the label read from inside of the bounds is, allowing for a few misread characters.
+// The icon next to each label is read as characters of its own, so each word is tried on its own
+func buttonLabel(bounds image.Rectangle, texts []ocr.Text) (Button, bool) {
+	for _, text := range texts {
+		if text.Box != "battleOption" || !text.Bounds.In(bounds) {
+			continue
+		}
+		for _, word := range text.Words {
+			// Characters that couldn't be read match any letter, so a word of nothing else could be any of the labels
+			read := strings.Trim(word.Text, string(ocr.Unknown))
+			if read == "" {
+				continue
+			}
+			for button := ButtonFight; button <= ButtonMercy; button++ {
+				label := button.String()
+				if ocr.Misreads(strings.ToUpper(read), label) <= len(label)/4 {
+					return button, true
+				}
+			}
+		}
+	}
+	return ButtonNone, false
+}
+
+// Determines if a button is highlighted, which is when it is yellow or the heart is on it
+func isHighlighted(button object.Object, hearts []image.Point) bool {
+	for _, heart := range hearts {
+		if heart.In(button.Bounds) {
+			return true
+		}
+	}
+	if button.EdgeColor == nil {
+		return false
+	}
+	// The highlighted button is the same yellow as the names of enemies that can be spared
+	return hud.Yellow(color.RGBAModel.Convert(button.EdgeColor).(color.RGBA))
+}
+
+// Menu gets the layout of the battle menu for navigating it. The buttons are in a single row that wraps around
+func (m BattleMenu) Menu() Menu {
+	return Menu{Name: "battle", Options: m.Bounds[:], Columns: len(m.Bounds), Wraps: true}
+}
@@ -0,0 +1,83 @@
+package ai
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+)
+
+// The colors of the battle buttons' outlines
+var (
+	buttonOrange = color.RGBA{255, 127, 39, 255}
+	buttonYellow = color.RGBA{255, 255, 0, 255}
+)
+
+// Where the buttons are in the game's window, from left to right
+var buttonBounds = []image.Rectangle{
+	image.Rect(32, 432, 139, 471),
+	image.Rect(185, 432, 292, 471),
+	image.Rect(345, 432, 452, 471),
+	image.Rect(500, 432, 607, 471),
+}
+
+// Makes a battle button object with the outline color given, moved by the offset
+func button(i int, col color.RGBA, offset image.Point) object.Object {
+	return object.Object{
+		Bounds:     buttonBounds[i].Add(offset),
+		EdgeColor:  col,
+		Recognized: true,
+		RecogObj:   object.RecognizedObject{Type: object.RecMap["battleOption"]},
+	}
+}
+
+func TestIsHighlighted(t *testing.T) {
+	if isHighlighted(button(0, buttonOrange, image.Point{}), nil) {
+		t.Errorf("the orange button is highlighted")
+	}
+	if !isHighlighted(button(0, buttonYellow, image.Point{}), nil) {
+		t.Errorf("the yellow button isn't highlighted")
+	}
+	// The heart is on the highlighted button, even if its color was read wrong
+	heart := []image.Point{{45, 451}}
+	if !isHighlighted(button(0, buttonOrange, image.Point{}), heart) {
+		t.Errorf("the button with the heart on it isn't highlighted")
+	}
+	if isHighlighted(object.Object{Bounds: buttonBounds[0]}, nil) {
+		t.Errorf("a button without an outline color is highlighted")
+	}
+}
+
+func TestReadBattleMenu(t *testing.T) {
+	screen := image.Rect(0, 0, 640, 480)
+
+	// All four buttons are in order from left to right, however the window is shifted and in whatever order they were found
+	shift := image.Point{90, 0}
+	objects := []object.Object{
+		button(2, buttonOrange, shift), button(0, buttonOrange, shift), button(3, buttonOrange, shift), button(1, buttonYellow, shift),
+	}
+	menu, ok := ReadBattleMenu(objects, nil, screen)
+	if !ok || menu.Selected != ButtonAct || menu.Bounds[ButtonMercy] != buttonBounds[3].Add(shift) {
+		t.Errorf("read the shifted buttons as %s with MERCY at %v", menu, menu.Bounds[ButtonMercy])
+	}
+
+	// With only some of the buttons found, the labels read from them decide which they are
+	objects = []object.Object{button(1, buttonOrange, shift), button(3, buttonYellow, shift)}
+	texts := []ocr.Text{
+		{Box: "battleOption", Bounds: buttonBounds[1].Add(shift).Inset(8), Words: []ocr.Word{{Text: "~"}, {Text: "ACT"}}},
+		{Box: "battleOption", Bounds: buttonBounds[3].Add(shift).Inset(8), Words: []ocr.Word{{Text: "~~"}, {Text: "MER~Y"}}},
+	}
+	menu, _ = ReadBattleMenu(objects, texts, screen)
+	if menu.String() != "ACT [MERCY]" {
+		t.Errorf("read the labelled buttons as %q", menu)
+	}
+
+	// Without labels, they fall back to the quarter of the screen they are in
+	objects = []object.Object{button(0, buttonOrange, image.Point{}), button(2, buttonYellow, image.Point{})}
+	menu, _ = ReadBattleMenu(objects, nil, screen)
+	if menu.String() != "FIGHT [ITEM]" {
+		t.Errorf("read the buttons as %q", menu)
+	}
+}
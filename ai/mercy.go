@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"strings"
+
+	"gitlab.com/256/Underbot/cv/hud"
+	"gitlab.com/256/Underbot/cv/ocr"
+)
+
+// Spareable determines if sparing will work, which is when Spare or any enemy's name has been shown in yellow
+func (e Encounter) Spareable() bool {
+	if e.CanSpare {
+		return true
+	}
+	for _, enemy := range e.Enemies {
+		if enemy.Spareable {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the MERCY menu is being shown in the current frame
+var mercyShown bool
+
+// Reads which enemies can be spared from the colors of the names in the narratorBox.
+// It returns whether the MERCY menu, which has Spare in it, is being shown
+func updateMercy(texts []ocr.Text) bool {
+	shown := false
+	for _, text := range texts {
+		if text.Box != "narratorBox" {
+			continue
+		}
+		seen := make(map[string]int)
+		for i, line := range text.Lines {
+			label := strings.TrimSpace(strings.TrimPrefix(line, "*"))
+			yellow := lineYellow(text.Words, i)
+			if ocr.Misreads(strings.ToLower(label), "spare") <= len("spare")/4 {
+				CurrentEncounter.CanSpare = yellow
+				shown = true
+				continue
+			}
+			// Enemies with the same name are listed in the same order as they are in the encounter
+			name, ok := enemyName(label)
+			if !ok {
+				continue
+			}
+			if enemy := nthEnemy(name, seen[name]); enemy != nil {
+				enemy.Spareable = yellow
+			}
+			seen[name]++
+		}
+	}
+	return shown
+}
+
+// Determines if any of the words on a line, other than the star that starts it, are yellow
+func lineYellow(words []ocr.Word, line int) bool {
+	for _, word := range words {
+		if word.Line == line && word.Text != "*" && hud.Yellow(word.Color) {
+			return true
+		}
+	}
+	return false
+}
+
+// Finds the nth enemy with the name given in the current encounter
+func nthEnemy(name string, n int) *Enemy {
+	for i := range CurrentEncounter.Enemies {
+		if CurrentEncounter.Enemies[i].Name != name {
+			continue
+		}
+		if n == 0 {
+			return &CurrentEncounter.Enemies[i]
+		}
+		n--
+	}
+	return nil
+}
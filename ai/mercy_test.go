@@ -0,0 +1,44 @@
+package ai
+
+import (
+	"image/color"
+	"testing"
+
+	"gitlab.com/256/Underbot/cv/ocr"
+)
+
+func TestUpdateMercy(t *testing.T) {
+	defer func() {
+		CurrentEncounter = Encounter{}
+	}()
+	white := color.RGBA{255, 255, 255, 255}
+	yellow := color.RGBA{255, 255, 0, 255}
+	menu := func(spare string, col color.RGBA) []ocr.Text {
+		return []ocr.Text{{Box: "narratorBox", Lines: []string{"* " + spare, "* Flee"}, Words: []ocr.Word{
+			{Text: "*", Line: 0, Color: white}, {Text: spare, Line: 0, Color: col},
+			{Text: "*", Line: 1, Color: white}, {Text: "Flee", Line: 1, Color: white},
+		}}}
+	}
+
+	tests := []struct {
+		name      string
+		texts     []ocr.Text
+		shown     bool
+		canSpare  bool
+		spareable bool
+	}{
+		{"yellow", menu("Spare", yellow), true, true, true},
+		{"white", menu("Spare", white), true, false, false},
+		{"misread character", menu("Spase", yellow), true, true, true},
+		{"unknown character", menu("Sp~re", yellow), true, true, true},
+		{"another menu", menu("Check", yellow), false, false, false},
+	}
+	for _, test := range tests {
+		CurrentEncounter = Encounter{}
+		shown := updateMercy(test.texts)
+		if shown != test.shown || CurrentEncounter.CanSpare != test.canSpare || CurrentEncounter.Spareable() != test.spareable {
+			t.Errorf("%s: shown %v, can spare %v, spareable %v, want %v, %v, %v", test.name,
+				shown, CurrentEncounter.CanSpare, CurrentEncounter.Spareable(), test.shown, test.canSpare, test.spareable)
+		}
+	}
+}
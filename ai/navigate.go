@@ -0,0 +1,170 @@
+package ai
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/rect"
+	"gitlab.com/256/Underbot/sys"
+)
+
+// Menu is the layout of a menu that the heart moves between the options of.
+// The options are laid out in a grid, from left to right and then top to bottom
+type Menu struct {
+	Name    string
+	Options []image.Rectangle // The bounds of each option. These can be empty when an option wasn't seen
+	Columns int
+	Wraps   bool // Whether moving past the first or last column of a row wraps around to the other end
+}
+
+// CursorAt finds the option that a heart at the point given is selecting. This is the option it is inside of,
+// or else the closest one to its right on the same row, as the heart is drawn just left of the options in lists
+func (m Menu) CursorAt(heart image.Point) int {
+	best := -1
+	bestDistance := 0
+	for i, option := range m.Options {
+		if option.Empty() {
+			continue
+		}
+		if heart.In(option) {
+			return i
+		}
+		distance := abs(option.Min.X-heart.X) + 4*abs(rect.RectangleCenter(option).Y-heart.Y)
+		if option.Min.X < heart.X {
+			// Options to the left of the heart are only picked if there is nothing else
+			distance += 1000
+		}
+		if best < 0 || distance < bestDistance {
+			best = i
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// Plan works out the keys that move the cursor from one option to another and then choose it
+func (m Menu) Plan(cursor, target int) []string {
+	columns := m.Columns
+	if columns < 1 {
+		columns = 1
+	}
+	var keys []string
+	row, column := cursor/columns, cursor%columns
+	targetRow, targetColumn := target/columns, target%columns
+
+	horizontal := func() {
+		moves := targetColumn - column
+		if m.Wraps && abs(moves) > columns/2 {
+			if moves > 0 {
+				moves -= columns
+			} else {
+				moves += columns
+			}
+		}
+		keys = append(keys, repeat("right", "left", moves)...)
+	}
+	vertical := func() {
+		keys = append(keys, repeat("down", "up", targetRow-row)...)
+	}
+
+	// The last row can be short, so the cursor moves along the row it is in first only if that row has the target's column
+	if row*columns+targetColumn < len(m.Options) {
+		horizontal()
+		vertical()
+	} else {
+		vertical()
+		horizontal()
+	}
+	return append(keys, "z")
+}
+
+// Repeats the first key for a positive amount of moves, or the second one for a negative amount
+func repeat(positive, negative string, moves int) []string {
+	key := positive
+	if moves < 0 {
+		key = negative
+	}
+	keys := make([]string, abs(moves))
+	for i := range keys {
+		keys[i] = key
+	}
+	return keys
+}
+
+// A key pressed by the navigator, which is checked against the cursor in later frames
+type navigation struct {
+	menu    string
+	from    int // Where the cursor was when the key was pressed
+	key     string
+	pressed time.Time
+	retries int
+}
+
+var lastNavigation navigation
+
+// When an option was last chosen. Nothing is pressed until the menu has had time to close
+var lastChosen time.Time
+
+// ErrStuck is the cause of the error Navigate returns when pressing a key again doesn't move the cursor or choose the option
+var ErrStuck = errors.New("the cursor won't move")
+
+// Navigate presses the next key that gets the cursor of a menu to the target and chooses it.
+// Keys are pressed one at a time, and if the cursor hasn't moved by the time it should have, the key is pressed again.
+// After choosing, nothing is pressed until the menu has had time to close, and the next visit to a menu starts over.
+// It returns true once the target is chosen, or an error caused by ErrStuck after pressing a key
+// NavigateRetries times more doesn't do anything
+func Navigate(win sys.Window, menu Menu, cursor, target int) (bool, error) {
+	if cursor < 0 || cursor >= len(menu.Options) || target < 0 || target >= len(menu.Options) {
+		return false, errors.New(fmt.Sprintf("can't navigate from %v to %v in the %s menu with %v options",
+			cursor, target, menu.Name, len(menu.Options)))
+	}
+
+	if time.Since(lastChosen) < params.NavigateSettle {
+		// Wait for the last choice to show up
+		return false, nil
+	}
+
+	retries := 0
+	last := lastNavigation
+	if last.menu == menu.Name && last.from == cursor && time.Since(last.pressed) < params.NavigateExpiry {
+		if time.Since(last.pressed) < params.NavigateSettle {
+			// Wait for the last key to show up
+			return false, nil
+		}
+		retries = last.retries + 1
+		if retries > params.NavigateRetries {
+			lastNavigation = navigation{}
+			return false, errors.Wrap(ErrStuck, fmt.Sprintf("pressed %s %v times in the %s menu", last.key, retries, menu.Name))
+		}
+	}
+
+	key := menu.Plan(cursor, target)[0]
+	err := win.Press(key)
+	if err != nil {
+		return false, errors.Wrap(err, fmt.Sprintf("failed to press %s in the %s menu", key, menu.Name))
+	}
+	if key == "z" {
+		lastNavigation = navigation{}
+		lastChosen = time.Now()
+		return true, nil
+	}
+	lastNavigation = navigation{
+		menu:    menu.Name,
+		from:    cursor,
+		key:     key,
+		pressed: time.Now(),
+		retries: retries,
+	}
+	return false, nil
+}
+
+// Gets the absolute value of a number
+func abs(num int) int {
+	if num < 0 {
+		return -num
+	}
+	return num
+}
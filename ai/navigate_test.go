@@ -0,0 +1,173 @@
+package ai
+
+import (
+	"image"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+// A row of four options like the battle buttons
+var row = Menu{Name: "row", Options: []image.Rectangle{
+	image.Rect(0, 0, 100, 40), image.Rect(150, 0, 250, 40), image.Rect(300, 0, 400, 40), image.Rect(450, 0, 550, 40),
+}, Columns: 4}
+
+// Makes the last key seem to have been pressed long enough ago for the cursor to have moved
+func settle() {
+	lastNavigation.pressed = lastNavigation.pressed.Add(-params.NavigateSettle)
+	lastChosen = lastChosen.Add(-params.NavigateSettle)
+}
+
+// Forgets the keys pressed by earlier tests
+func resetNavigation() {
+	lastNavigation = navigation{}
+	lastChosen = time.Time{}
+}
+
+func TestPlan(t *testing.T) {
+	grid := Menu{Name: "grid", Options: make([]image.Rectangle, 5), Columns: 2}
+	tests := []struct {
+		menu           Menu
+		cursor, target int
+		want           string
+	}{
+		{row, 0, 2, "right right z"},
+		{row, 3, 1, "left left z"},
+		{row, 2, 2, "z"},
+		{Menu{Name: "wrapping", Options: row.Options, Columns: 4, Wraps: true}, 0, 3, "left z"},
+		{grid, 0, 3, "right down z"},
+		// The last row only has the first column, so the cursor goes left before down
+		{grid, 1, 4, "left down down z"},
+	}
+	for _, test := range tests {
+		got := strings.Join(test.menu.Plan(test.cursor, test.target), " ")
+		if got != test.want {
+			t.Errorf("planned %q from %v to %v in the %s menu, want %q", got, test.cursor, test.target, test.menu.Name, test.want)
+		}
+	}
+}
+
+func TestNavigate(t *testing.T) {
+	defer func() {
+		resetNavigation()
+	}()
+	resetNavigation()
+	win := newFakeWindow()
+
+	// Keys are pressed one at a time, waiting for the cursor to move
+	chosen, err := Navigate(win, row, 0, 1)
+	if err != nil || chosen {
+		t.Fatalf("navigating returned %v, %v", chosen, err)
+	}
+	Navigate(win, row, 0, 1)
+	if pressed := win.take(); strings.Join(pressed, " ") != "right" {
+		t.Errorf("pressed %q before the cursor could move, want right once", pressed)
+	}
+	chosen, err = Navigate(win, row, 1, 1)
+	if err != nil || !chosen {
+		t.Fatalf("choosing returned %v, %v", chosen, err)
+	}
+
+	// Choosing waits for the menu to close too
+	Navigate(win, row, 1, 1)
+	if pressed := win.take(); strings.Join(pressed, " ") != "z" {
+		t.Errorf("pressed %q before the menu could close, want z once", pressed)
+	}
+}
+
+func TestNavigateAgain(t *testing.T) {
+	defer resetNavigation()
+	resetNavigation()
+	win := newFakeWindow()
+
+	// Visiting the same menu with the cursor in the same place again, like saving at every checkpoint
+	// or picking the first enemy and then the first act, is a new visit each time rather than a retry
+	for i := 0; i <= params.NavigateRetries+1; i++ {
+		settle()
+		chosen, err := Navigate(win, row, 0, 0)
+		if err != nil || !chosen {
+			t.Fatalf("choosing the %vth time returned %v, %v", i+1, chosen, err)
+		}
+	}
+	if pressed := win.take(); len(pressed) != params.NavigateRetries+2 {
+		t.Errorf("pressed %q, want z %v times", pressed, params.NavigateRetries+2)
+	}
+}
+
+func TestNavigateExpiry(t *testing.T) {
+	defer resetNavigation()
+	resetNavigation()
+	win := newFakeWindow()
+
+	// A press long ago that didn't seem to work isn't retried on the next visit
+	lastNavigation = navigation{menu: row.Name, from: 0, key: "right", pressed: time.Now().Add(-time.Minute),
+		retries: params.NavigateRetries}
+	_, err := Navigate(win, row, 0, 1)
+	if err != nil {
+		t.Errorf("got %v coming back to the menu after a long time", err)
+	}
+	if lastNavigation.retries != 0 {
+		t.Errorf("counted %v retries coming back to the menu after a long time", lastNavigation.retries)
+	}
+}
+
+func TestNavigateStuck(t *testing.T) {
+	defer func() {
+		resetNavigation()
+	}()
+	resetNavigation()
+	win := newFakeWindow()
+
+	var err error
+	for i := 0; i <= params.NavigateRetries; i++ {
+		_, err = Navigate(win, row, 0, 2)
+		if err != nil {
+			t.Fatalf("gave up after %v presses: %v", i+1, err)
+		}
+		settle()
+	}
+	if pressed := win.take(); len(pressed) != params.NavigateRetries+1 {
+		t.Errorf("pressed %q, want right %v times", pressed, params.NavigateRetries+1)
+	}
+	_, err = Navigate(win, row, 0, 2)
+	if errors.Cause(err) != ErrStuck {
+		t.Errorf("got %v once the cursor wouldn't move, want ErrStuck", err)
+	}
+
+	// Giving up starts over the next time
+	_, err = Navigate(win, row, 0, 2)
+	if err != nil {
+		t.Errorf("got %v navigating after giving up", err)
+	}
+}
+
+// TestDismissSaved checks that the message saying the file was saved is only dismissed once it has had time to show up,
+// rather than with a second z right after the one choosing Save
+func TestDismissSaved(t *testing.T) {
+	defer func() {
+		savedAt = time.Time{}
+	}()
+	win := newFakeWindow()
+
+	savedAt = time.Now()
+	err := dismissSaved(win)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pressed := win.take(); len(pressed) != 0 {
+		t.Errorf("pressed %q right after saving", pressed)
+	}
+	savedAt = savedAt.Add(-params.NavigateSettle)
+	for i := 0; i < 2; i++ {
+		err = dismissSaved(win)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pressed := win.take(); strings.Join(pressed, " ") != "z" {
+		t.Errorf("pressed %q to dismiss the message, want z once", pressed)
+	}
+}
@@ -0,0 +1,172 @@
+package ai
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"gitlab.com/256/Underbot/cv/mask"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/rect"
+)
+
+// Projectile is an attack inside of the fightBox that the heart has to avoid
+type Projectile struct {
+	Object    object.Object
+	Bounds    image.Rectangle
+	TrackID   int               // Stays the same for the projectile across frames, or 0 if it was just found
+	Velocity  object.Velocity   // In pixels per second
+	Predicted []image.Rectangle // Where the projectile is expected to be in each of the next params.PredictFrames frames
+	Color     AttackColor
+	Mask      mask.Mask // The pixels the projectile covers in the current frame
+}
+
+// AttackColor is the kind of attack a projectile is, based on its color
+type AttackColor int
+
+// The kinds of attacks. Blue attacks only hurt if the heart moves, and orange attacks only hurt if it stays still
+const (
+	AttackWhite AttackColor = iota
+	AttackBlue              // Includes the cyan variants
+	AttackOrange
+	AttackOther // Any other color, which is treated like a white attack
+)
+
+// String gets the name of the attack color
+func (c AttackColor) String() string {
+	switch c {
+	case AttackWhite:
+		return "white"
+	case AttackBlue:
+		return "blue"
+	case AttackOrange:
+		return "orange"
+	}
+	return "other"
+}
+
+// ClassifyColor determines the kind of attack from the color of a projectile
+func ClassifyColor(col color.Color) AttackColor {
+	hue, sat, val := hsv(col)
+	switch {
+	case val < 0.2:
+		return AttackOther
+	case sat < 0.25:
+		return AttackWhite
+	case hue >= 170 && hue <= 250:
+		return AttackBlue
+	case hue >= 20 && hue <= 50:
+		return AttackOrange
+	}
+	return AttackOther
+}
+
+// Converts a color to its hue (0 to 360), saturation (0 to 1) and value (0 to 1)
+func hsv(col color.Color) (float64, float64, float64) {
+	r16, g16, b16, _ := col.RGBA()
+	r, g, b := float64(r16)/0xffff, float64(g16)/0xffff, float64(b16)/0xffff
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	delta := max - min
+	if max == 0 {
+		return 0, 0, 0
+	}
+	if delta == 0 {
+		return 0, 0, max
+	}
+
+	var hue float64
+	switch max {
+	case r:
+		hue = 60 * math.Mod((g-b)/delta, 6)
+	case g:
+		hue = 60 * ((b-r)/delta + 2)
+	default:
+		hue = 60 * ((r-g)/delta + 4)
+	}
+	if hue < 0 {
+		hue += 360
+	}
+	return hue, delta / max, max
+}
+
+// Projectiles holds the projectiles found in the current frame
+var Projectiles []Projectile
+
+// FindProjectiles classifies everything inside of the fightBox other than the heart as a projectile
+func FindProjectiles(objects []object.Object) []Projectile {
+	var found []Projectile
+	for i := range objects {
+		if objects[i].Recognized && objects[i].RecogObj.Type.Is(object.RecMap["fightBox"]) {
+			found = append(found, projectilesIn(&objects[i], &objects[i])...)
+		}
+	}
+	return found
+}
+
+// Finds the projectiles among the children of an object inside of the box
+func projectilesIn(box *object.Object, parent *object.Object) []Projectile {
+	var found []Projectile
+	for _, child := range parent.Children {
+		switch {
+		case isBorder(box, child):
+			// The inside edge of the box's border, which holds everything in the box
+			found = append(found, projectilesIn(box, child)...)
+		case isHeart(child):
+		default:
+			// Anything inside of a projectile (such as the inside edge of a ring) is part of that projectile
+			found = append(found, newProjectile(*child))
+		}
+	}
+	return found
+}
+
+// Creates a Projectile from an object, predicting where it is going from its motion
+func newProjectile(obj object.Object) Projectile {
+	proj := Projectile{
+		Object:   obj,
+		Bounds:   obj.Bounds,
+		TrackID:  obj.Motion.TrackID,
+		Velocity: obj.Motion.Velocity,
+		Color:    projectileColor(obj),
+		Mask:     obj.Mask(),
+	}
+	for i := 1; i <= params.PredictFrames; i++ {
+		proj.Predicted = append(proj.Predicted, obj.Motion.Predict(time.Duration(i)*params.GameFrame))
+	}
+	return proj
+}
+
+// Determines the kind of attack a projectile is from the color of its outline, as hollow attacks such as rings have
+// the background in their center. The center is only used for objects without an outline
+func projectileColor(obj object.Object) AttackColor {
+	if len(obj.Contour) > 0 && obj.EdgeColor != nil {
+		return ClassifyColor(obj.EdgeColor)
+	}
+	return ClassifyColor(obj.Color)
+}
+
+// Determines if an object inside of the box is the inside edge of its border, which hugs every side of the box.
+// Large attacks, like walls of bones, can cover most of the box too, but they never line up with all of its sides
+func isBorder(box *object.Object, obj *object.Object) bool {
+	inner, outer := obj.Bounds, box.Bounds
+	return inner.Min.X-outer.Min.X <= params.FightBoxBorder && inner.Min.Y-outer.Min.Y <= params.FightBoxBorder &&
+		outer.Max.X-inner.Max.X <= params.FightBoxBorder && outer.Max.Y-inner.Max.Y <= params.FightBoxBorder
+}
+
+// Determines if an object was recognized as one of the hearts
+func isHeart(obj *object.Object) bool {
+	return obj.Recognized && contains(object.Hearts, obj.RecogObj.Type)
+}
+
+// Draws the projectiles and where they are predicted to go onto the image
+func drawProjectiles(img *image.RGBA, projectiles []Projectile) {
+	for _, proj := range projectiles {
+		rect.DrawRectangle(img, params.ProjectileColor, proj.Bounds)
+		if len(proj.Predicted) > 0 && proj.Velocity != (object.Velocity{}) {
+			rect.DrawRectangle(img, params.ProjectileColor, proj.Predicted[len(proj.Predicted)-1])
+		}
+	}
+}
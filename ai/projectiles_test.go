@@ -0,0 +1,48 @@
+package ai
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"gitlab.com/256/Underbot/cv/object"
+)
+
+func TestFindProjectiles(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	blue := color.RGBA{20, 169, 255, 255}
+	orange := color.RGBA{252, 166, 0, 255}
+	outline := []image.Point{{0, 0}, {1, 1}}
+
+	objects := []object.Object{
+		{ID: 1, Bounds: image.Rect(200, 200, 400, 400), Color: black,
+			Recognized: true, RecogObj: object.RecognizedObject{Type: object.RecMap["fightBox"]}},
+		// The inside edge of the border
+		{ID: 2, Bounds: image.Rect(205, 205, 395, 395), Color: black, EdgeColor: white, Contour: outline},
+		// A wall of bones covering most of the box, which isn't the border as it doesn't reach the right side
+		{ID: 3, Bounds: image.Rect(206, 206, 330, 394), Color: white, EdgeColor: white, Contour: outline},
+		// A blue ring, which is the background color in its center
+		{ID: 4, Bounds: image.Rect(340, 220, 370, 250), Color: black, EdgeColor: blue, Contour: outline},
+		// An orange bullet without an outline
+		{ID: 5, Bounds: image.Rect(340, 300, 350, 310), Color: orange},
+	}
+	parents := []int{-1, 0, 1, 1, 1}
+	object.Link(objects, parents)
+
+	found := FindProjectiles(objects)
+	want := map[int]AttackColor{3: AttackWhite, 4: AttackBlue, 5: AttackOrange}
+	if len(found) != len(want) {
+		t.Fatalf("found %v projectiles, want %v", len(found), len(want))
+	}
+	for _, proj := range found {
+		color, ok := want[proj.Object.ID]
+		if !ok {
+			t.Errorf("object %v was found as a projectile", proj.Object.ID)
+			continue
+		}
+		if proj.Color != color {
+			t.Errorf("object %v is a %s attack, want %s", proj.Object.ID, proj.Color, color)
+		}
+	}
+}
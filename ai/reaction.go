@@ -0,0 +1,85 @@
+package ai
+
+import (
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/mask"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/sys"
+)
+
+// Reaction is how the heart has to move to get through the colored attacks about to hit it
+type Reaction int
+
+// The possible reactions
+const (
+	ReactFree       Reaction = iota // No colored attack is about to hit, so the heart can move however it wants
+	ReactHoldStill                  // A blue attack is about to hit, which only hurts if the heart moves
+	ReactKeepMoving                 // An orange attack is about to hit, which only hurts if the heart stays still
+)
+
+// String gets the name of the reaction
+func (r Reaction) String() string {
+	switch r {
+	case ReactHoldStill:
+		return "hold still"
+	case ReactKeepMoving:
+		return "keep moving"
+	}
+	return "free"
+}
+
+// CurrentReaction holds the reaction decided in the current frame
+var CurrentReaction Reaction
+
+// The direction the heart last wiggled in to keep moving
+var wiggleLeft bool
+
+// React decides how the heart should move based on the colored attack that will hit it first
+func React(hitbox mask.Mask, projectiles []Projectile) Reaction {
+	soonest := params.PredictFrames + 1
+	reaction := ReactFree
+	for _, proj := range projectiles {
+		if proj.Color != AttackBlue && proj.Color != AttackOrange {
+			continue
+		}
+		hit := framesUntilHit(hitbox, proj)
+		if hit < 0 || hit >= soonest {
+			continue
+		}
+		soonest = hit
+		if proj.Color == AttackBlue {
+			reaction = ReactHoldStill
+		} else {
+			reaction = ReactKeepMoving
+		}
+	}
+	return reaction
+}
+
+// Gets how many frames it will take for the projectile to reach the heart, or -1 if it won't in the frames predicted
+func framesUntilHit(hitbox mask.Mask, proj Projectile) int {
+	for ahead := 0; ahead <= len(proj.Predicted); ahead++ {
+		if Collides(hitbox, proj, ahead) {
+			return ahead
+		}
+	}
+	return -1
+}
+
+// Carries out a reaction. Holding still and moving freely don't need any keys pressed here
+func react(win sys.Window, reaction Reaction) error {
+	if reaction != ReactKeepMoving {
+		return nil
+	}
+	// Wiggle back and forth so the heart keeps moving without going anywhere
+	wiggleLeft = !wiggleLeft
+	key := "right"
+	if wiggleLeft {
+		key = "left"
+	}
+	err := win.Press(key)
+	if err != nil {
+		return errors.Wrap(err, "failed to press the key to keep moving")
+	}
+	return nil
+}
@@ -18,6 +18,17 @@ type State struct {
 	// An update function called every frame for the specific state to handle
 	updateFun func([]object.Object, sys.Window, *image.RGBA) error
 	times     int // Specifies how many times per 10 frames the function should run. If -1, then run all the time. Limited 5
+	focus     Focus
+	// Whether the state needs to see frames even when nothing changed, such as to notice that text finished typing
+	duplicates bool
+}
+
+// Focus lets a state limit the CV to the areas around certain objects and to some of the recognizers,
+// which cuts the time it takes to process each frame
+type Focus struct {
+	Anchors     []object.RecognizableObject // The objects whose areas are processed
+	Margin      int                         // How many pixels around each anchor are also processed
+	Recognizers []object.RecognizableObject // The recognizers to use. The state's signs are always included
 }
 
 // NewState creates new State instance with parameter checking
@@ -32,6 +43,23 @@ func NewState(name string, signs []object.RecognizableObject, antiSigns []object
 
 }
 
+// WithFocus returns a copy of the state that only has the CV look at the areas and recognizers in focus
+func (state State) WithFocus(focus Focus) State {
+	state.focus = focus
+	return state
+}
+
+// WithDuplicates returns a copy of the state that is updated on every frame, including the ones that didn't change
+func (state State) WithDuplicates() State {
+	state.duplicates = true
+	return state
+}
+
+// Duplicates reports whether the state needs to be updated on frames that didn't change
+func (state State) Duplicates() bool {
+	return state.duplicates
+}
+
 // Checks a State instance for validity
 func (state *State) check() error {
 	if state.Name == "" {
@@ -56,7 +84,8 @@ var inBattleSigns = append([]object.RecognizableObject{
 	object.RecMap["fightBox"], // fightBox
 }, object.Hearts...)
 
-var dialogueSigns = object.Dialogue
+// The heart is the cursor of the choices asked in dialogue, so it is a sign too
+var dialogueSigns = append(append([]object.RecognizableObject{}, object.Dialogue...), object.Hearts...)
 
 var dialogueAntiSigns = []object.RecognizableObject{
 	object.RecMap["attackGoal"], // attackGoal
@@ -69,6 +98,15 @@ var saveScreenSigns = []object.RecognizableObject{
 	object.RecMap["saveBox"],  // saveBox
 	object.RecMap["redHeart"], // redHeart
 }
+
+// While attacked, the fightBox and what is inside of it matters, along with the battle buttons that the HUD row is
+// located from, as hits are counted from the HP it shows
+var inBattleFocus = Focus{
+	Anchors:     []object.RecognizableObject{object.RecMap["fightBox"], object.RecMap["battleOption"]},
+	Margin:      10,
+	Recognizers: []object.RecognizableObject{object.RecMap["battleOption"]},
+}
+
 var attackGoalSigns = []object.RecognizableObject{
 	object.RecMap["attackGoal"], // attackGoal
 	object.RecMap["attackPeg"],  // attackPeg
@@ -80,11 +118,11 @@ var States = []State{
 	NewState("Unknown", emptyObjects, emptyObjects, UnknownUpdate, -1),
 	// After encountering a battle when no option has been pressed yet
 	NewState("battleMenu", battleMenuSigns, emptyObjects, BattleMenuUpdate, -1),
-	NewState("inBattle", inBattleSigns, emptyObjects, InBattleUpdate, -1),                // When the opponent is attacking
-	NewState("dialogue", dialogueSigns, dialogueAntiSigns, DialogueUpdate, 5),            // When outside battle with dialogue
-	NewState("outsideBattle", outsideBattleSigns, emptyObjects, OutsideBattleUpdate, -1), // When outside battle
+	NewState("inBattle", inBattleSigns, emptyObjects, InBattleUpdate, -1).WithFocus(inBattleFocus), // When attacked
+	NewState("dialogue", dialogueSigns, dialogueAntiSigns, DialogueUpdate, 5).WithDuplicates(),     // When outside battle with dialogue
+	NewState("outsideBattle", outsideBattleSigns, emptyObjects, OutsideBattleUpdate, -1),           // When outside battle
 	// The screen where you can choose to "Save" or "Return" at a checkpoint
 	NewState("saveScreen", saveScreenSigns, emptyObjects, SaveUpdate, -1),
 	// After pressing fight, when z needs to be pressed with good timing
-	NewState("attackGoal", attackGoalSigns, emptyObjects, BattleMenuUpdate, -1),
+	NewState("attackGoal", attackGoalSigns, emptyObjects, AttackUpdate, -1),
 }
@@ -0,0 +1,43 @@
+package ai
+
+import (
+	"image"
+	"testing"
+
+	"gitlab.com/256/Underbot/cv/hud"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+// TestInBattleFocus checks that the battle buttons stay in view while attacked, so the HUD row is found from them
+func TestInBattleFocus(t *testing.T) {
+	defer func(state State) {
+		CurrentState = state
+	}(CurrentState)
+	for _, state := range States {
+		if state.Name == "inBattle" {
+			CurrentState = state
+		}
+	}
+
+	// Shifted down from where the HUD row is assumed to be
+	button := image.Rect(32, 442, 142, 484)
+	recognized := []object.Object{
+		{ID: 1, Bounds: image.Rect(32, 250, 607, 390), Recognized: true, RecogObj: object.RecognizedObject{Type: object.RecMap["fightBox"]}},
+		{ID: 2, Bounds: button, Recognized: true, RecogObj: object.RecognizedObject{Type: object.RecMap["battleOption"]}},
+	}
+	regions, recognizers := FocusRegions(recognized)
+	seen := false
+	for _, region := range regions {
+		seen = seen || button.In(region)
+	}
+	if !seen {
+		t.Errorf("the battle button at %v isn't in the regions %v", button, regions)
+	}
+	if !contains(recognizers, object.RecMap["battleOption"]) {
+		t.Errorf("the battle buttons aren't recognized while attacked")
+	}
+	if hud.Locate(recognized) == params.HUDRegion {
+		t.Errorf("the HUD row wasn't located from the battle buttons")
+	}
+}
@@ -0,0 +1,178 @@
+package ai
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/rect"
+	"gitlab.com/256/Underbot/sys"
+)
+
+// Submenu is the list shown in the narratorBox after choosing one of the battle buttons,
+// such as the enemies to target, the acts or the items
+type Submenu struct {
+	Options []Option // From left to right, then top to bottom
+	Cursor  int      // The index of the option that the heart is next to
+}
+
+// String describes the submenu with the option under the cursor in brackets
+func (s Submenu) String() string {
+	labels := make([]string, len(s.Options))
+	for i, option := range s.Options {
+		labels[i] = option.Text
+		if i == s.Cursor {
+			labels[i] = fmt.Sprintf("[%s]", option.Text)
+		}
+	}
+	return strings.Join(labels, " ")
+}
+
+// Find gets the index of the option with the label given, allowing for a few misread characters, or -1 if there isn't one
+func (s Submenu) Find(label string) int {
+	for i, option := range s.Options {
+		if ocr.Misreads(strings.ToLower(option.Text), strings.ToLower(label)) <= len(label)/4 {
+			return i
+		}
+	}
+	return -1
+}
+
+// CurrentSubmenu holds the submenu shown in the current frame, or nil if there isn't one
+var CurrentSubmenu *Submenu
+
+// Selection is an option that a strategy wants picked from the submenu of one of the battle buttons
+type Selection struct {
+	Button Button // The button whose submenus the option is in
+	Label  string
+	Queued time.Time
+}
+
+// Selections holds the options that strategies want picked from the submenus, oldest first.
+// Whenever a submenu of the button shows one of them, the oldest one it shows is selected and removed
+var Selections []Selection
+
+// Select asks for the options with the labels given to be picked, in order, from the submenus of a button.
+// They replace any options still waiting to be picked from its submenus
+func Select(button Button, labels ...string) {
+	kept := Selections[:0]
+	for _, selection := range Selections {
+		if selection.Button != button {
+			kept = append(kept, selection)
+		}
+	}
+	Selections = kept
+	for _, label := range labels {
+		Selections = append(Selections, Selection{Button: button, Label: label, Queued: time.Now()})
+	}
+}
+
+// Forgets the selections that have been waiting for too long, as their submenu has most likely been left
+func expireSelections(at time.Time) {
+	kept := Selections[:0]
+	for _, selection := range Selections {
+		if at.Sub(selection.Queued) < params.SelectionTimeout {
+			kept = append(kept, selection)
+		}
+	}
+	Selections = kept
+}
+
+// Finds the oldest selection for the button that the submenu shows, returning its index in Selections and the option's
+func (s Submenu) wanted(button Button) (int, int) {
+	for i, selection := range Selections {
+		if selection.Button != button {
+			continue
+		}
+		if option := s.Find(selection.Label); option >= 0 {
+			return i, option
+		}
+	}
+	return -1, -1
+}
+
+// ReadSubmenu turns the text in the narratorBox into a submenu. The heart being in the box is what tells a submenu
+// apart from the narrator's text, so it returns false if it isn't
+func ReadSubmenu(texts []ocr.Text, hearts []object.Object) (Submenu, bool) {
+	for _, text := range texts {
+		if text.Box != "narratorBox" {
+			continue
+		}
+		for _, heart := range hearts {
+			center := rect.RectangleCenter(heart.Bounds)
+			if !center.In(text.Bounds) {
+				continue
+			}
+			var menu Submenu
+			for line := range text.Lines {
+				for _, option := range options(text.Words, line) {
+					// Each option starts with a star, which isn't part of its label
+					option.Text = strings.TrimSpace(strings.TrimPrefix(option.Text, "*"))
+					if option.Text != "" {
+						menu.Options = append(menu.Options, option)
+					}
+				}
+			}
+			if len(menu.Options) == 0 {
+				continue
+			}
+			menu.Cursor = menu.Menu().CursorAt(center)
+			return menu, true
+		}
+	}
+	return Submenu{}, false
+}
+
+// Menu gets the layout of the submenu. Its options are in as many columns as there are on the first row
+func (s Submenu) Menu() Menu {
+	menu := Menu{Name: "submenu", Options: make([]image.Rectangle, len(s.Options))}
+	for i, option := range s.Options {
+		menu.Options[i] = option.Bounds
+		if rect.RectangleCenter(option.Bounds).Y < s.Options[0].Bounds.Max.Y {
+			menu.Columns++
+		}
+	}
+	return menu
+}
+
+// Picks an option from the submenu of a button. The oldest selection for the button that the submenu shows is used.
+// Without one, the option under the cursor is only confirmed where that is what the button is for: attacking the first
+// target for FIGHT, and using the first item for ITEM when healing. Any other submenu is backed out of
+func handleSubmenu(win sys.Window, menu Submenu, button Button) error {
+	expireSelections(time.Now())
+	selection, target := menu.wanted(button)
+	if selection < 0 {
+		if button != ButtonFight && button != ButtonItem {
+			err := win.Press("x")
+			if err != nil {
+				return errors.Wrap(err, "failed to back out of the submenu")
+			}
+			return nil
+		}
+		target = menu.Cursor
+	}
+	chosen, err := Navigate(win, menu.Menu(), menu.Cursor, target)
+	if errors.Cause(err) == ErrStuck {
+		// Give up on the option and leave the submenu, so that the battle menu can pick something else
+		if selection >= 0 {
+			Selections = append(Selections[:selection], Selections[selection+1:]...)
+		}
+		err = win.Press("x")
+		if err != nil {
+			return errors.Wrap(err, "failed to back out of the submenu")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if chosen && selection >= 0 {
+		Selections = append(Selections[:selection], Selections[selection+1:]...)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"image"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Lays out a submenu with two options on each row, with the cursor on the first
+func submenuOf(labels ...string) Submenu {
+	var menu Submenu
+	for i, label := range labels {
+		at := image.Pt(100+i%2*200, 280+i/2*32)
+		menu.Options = append(menu.Options, Option{Text: label, Bounds: image.Rectangle{at, at.Add(image.Pt(120, 24))}})
+	}
+	return menu
+}
+
+func TestHandleSubmenu(t *testing.T) {
+	defer func() {
+		Selections = nil
+		resetNavigation()
+	}()
+
+	tests := []struct {
+		name       string
+		button     Button
+		selections []Selection
+		want       string   // The key pressed
+		left       []string // The labels of the selections left afterwards
+	}{
+		{"nothing queued for FIGHT", ButtonFight, nil, "z", nil},
+		{"nothing queued for ACT", ButtonAct, nil, "x", nil},
+		{"queued option", ButtonAct, []Selection{{ButtonAct, "Talk", time.Now()}}, "right", []string{"Talk"}},
+		{"queued option under the cursor", ButtonAct, []Selection{{ButtonAct, "Check", time.Now()}}, "z", nil},
+		{"unshown head", ButtonAct,
+			[]Selection{{ButtonAct, "Pet", time.Now()}, {ButtonAct, "Talk", time.Now()}}, "right", []string{"Pet", "Talk"}},
+		{"other button's option", ButtonAct, []Selection{{ButtonItem, "Talk", time.Now()}}, "x", []string{"Talk"}},
+		{"expired option", ButtonAct, []Selection{{ButtonAct, "Talk", time.Now().Add(-time.Minute)}}, "x", nil},
+	}
+	for _, test := range tests {
+		Selections = append([]Selection{}, test.selections...)
+		resetNavigation()
+		win := newFakeWindow()
+		err := handleSubmenu(win, submenuOf("Check", "Talk"), test.button)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pressed := win.take()
+		if strings.Join(pressed, " ") != test.want {
+			t.Errorf("%s: pressed %q, want %q", test.name, pressed, test.want)
+		}
+		var left []string
+		for _, selection := range Selections {
+			left = append(left, selection.Label)
+		}
+		if strings.Join(left, " ") != strings.Join(test.left, " ") {
+			t.Errorf("%s: left %q queued, want %q", test.name, left, test.left)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	defer func() {
+		Selections = nil
+	}()
+
+	Selections = nil
+	Select(ButtonAct, "Dummy", "Talk")
+	Select(ButtonItem, "Pie")
+	Select(ButtonAct, "Froggit", "Compliment")
+	var queued []string
+	for _, selection := range Selections {
+		queued = append(queued, selection.Label)
+	}
+	if strings.Join(queued, "|") != "Pie|Froggit|Compliment" {
+		t.Errorf("queued %q, want the later ACT options to replace the earlier ones", queued)
+	}
+}
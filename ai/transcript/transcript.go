@@ -0,0 +1,205 @@
+// Package transcript records every line of dialogue read during a session, so that runs can be looked through afterwards
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+)
+
+// Entry is a single line of dialogue, as it looked once it finished typing out
+type Entry struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"` // When the line was last seen
+	Box     string    `json:"box"`
+	Speaker string    `json:"speaker"`
+	Font    string    `json:"font"`
+	Text    string    `json:"text"`
+	State   string    `json:"state"`  // The state the AI was in when the line appeared
+	Frames  int       `json:"frames"` // How many frames the line was seen in, which is high wherever the AI got stuck
+}
+
+// Kept is how many of the latest entries are kept in memory for Entries. Every entry is still written out
+var Kept = 200
+
+// Transcript collects the lines of a session, writing each one out as a line of JSON once it is gone from the screen.
+// It is safe to use from several goroutines
+type Transcript struct {
+	mutex   sync.Mutex
+	out     io.Writer
+	entries []Entry
+	open    map[string]*Entry // The line currently shown in each box, which may still be typing out
+}
+
+// New creates a Transcript that writes its entries to out
+func New(out io.Writer) *Transcript {
+	return &Transcript{out: out, open: make(map[string]*Entry)}
+}
+
+// Observe records the text read from a frame. The entities and state are used to fill in new entries
+func (t *Transcript) Observe(texts []ocr.Text, entities []object.Entity, state string, at time.Time) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	seen := make(map[string]bool)
+	for _, text := range texts {
+		line := text.String()
+		if line == "" || !dialogue(text) {
+			continue
+		}
+		seen[text.Box] = true
+
+		// While the text is typing out, each frame only adds to the end of the line
+		current := t.open[text.Box]
+		if current != nil && continues(current.Text, line) {
+			if len(line) > len(current.Text) {
+				current.Text = line
+			}
+			current.End = at
+			current.Frames++
+			continue
+		}
+
+		if current != nil {
+			err := t.finish(text.Box)
+			if err != nil {
+				return errors.Wrap(err, "failed to finish the last line")
+			}
+		}
+		t.open[text.Box] = &Entry{
+			Start:   at,
+			End:     at,
+			Box:     text.Box,
+			Speaker: Guess(text, entities),
+			Font:    text.Font,
+			Text:    line,
+			State:   state,
+			Frames:  1,
+		}
+	}
+
+	// Lines whose box is gone are finished
+	for box := range t.open {
+		if !seen[box] {
+			err := t.finish(box)
+			if err != nil {
+				return errors.Wrap(err, "failed to finish a line whose box closed")
+			}
+		}
+	}
+	return nil
+}
+
+// Determines if text was read from one of the boxes that dialogue is shown in, rather than from a label such as a button's
+func dialogue(text ocr.Text) bool {
+	for _, box := range object.TextBoxes {
+		if text.Box == box.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// Determines if a line read from a frame is the same as the one already open, or more or less of it as it types out.
+// Up to a quarter of the characters can be misread, so a single misread character doesn't start a new line
+func continues(open, line string) bool {
+	a, b := []rune(open), []rune(line)
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	// The shorter of the two is compared with as much of the start of the longer one
+	return ocr.Misreads(string(a), string(b[:len(a)])) <= len(a)/4
+}
+
+// Close finishes every line that is still on screen
+func (t *Transcript) Close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for box := range t.open {
+		err := t.finish(box)
+		if err != nil {
+			return errors.Wrap(err, "failed to finish the lines left on screen")
+		}
+	}
+	return nil
+}
+
+// Entries returns the latest finished lines of the session, up to Kept of them
+func (t *Transcript) Entries() []Entry {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([]Entry{}, t.entries...)
+}
+
+// Writes out the line shown in a box and stops tracking it
+func (t *Transcript) finish(box string) error {
+	entry := t.open[box]
+	delete(t.open, box)
+	t.entries = append(t.entries, *entry)
+	if len(t.entries) > Kept {
+		t.entries = t.entries[len(t.entries)-Kept:]
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the entry")
+	}
+	_, err = t.out.Write(append(encoded, '\n'))
+	if err != nil {
+		return errors.Wrap(err, "failed to write the entry")
+	}
+	return nil
+}
+
+// Guess guesses who is talking from the font of the text and who is around
+func Guess(text ocr.Text, entities []object.Entity) string {
+	switch {
+	case text.Font == ocr.Sans.Name:
+		return "sans"
+	case text.Font == ocr.Papyrus.Name:
+		return "papyrus"
+	case text.Box == "narratorBox":
+		return "narrator"
+	}
+	// Anyone other than Frisk on screen is most likely who is talking
+	for _, entity := range entities {
+		if entity.Name != "frisk" {
+			return entity.Name
+		}
+	}
+	return "narrator"
+}
+
+// Search reads the entries of a transcript, returning the ones whose text or speaker contains the query.
+// Upper and lower case are treated the same
+func Search(in io.Reader, query string) ([]Entry, error) {
+	query = strings.ToLower(query)
+	var found []Entry
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		var entry Entry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode an entry")
+		}
+		if strings.Contains(strings.ToLower(entry.Text), query) || strings.Contains(strings.ToLower(entry.Speaker), query) {
+			found = append(found, entry)
+		}
+	}
+	if scanner.Err() != nil {
+		return nil, errors.Wrap(scanner.Err(), "failed to read the transcript")
+	}
+	return found, nil
+}
+
+// String describes the entry on a single line
+func (e Entry) String() string {
+	return fmt.Sprintf("%s [%s, %v frames] %s: %s", e.Start.Format("15:04:05"), e.State, e.Frames, e.Speaker, e.Text)
+}
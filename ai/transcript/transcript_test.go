@@ -0,0 +1,104 @@
+package transcript
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/256/Underbot/cv/ocr"
+)
+
+// Observes the frames given, each holding the text read from the dialogueBox or nothing if it is closed
+func observe(t *testing.T, tr *Transcript, frames ...string) {
+	start := time.Date(2018, 9, 15, 12, 0, 0, 0, time.UTC)
+	for i, frame := range frames {
+		var texts []ocr.Text
+		if frame != "" {
+			texts = []ocr.Text{{Box: "dialogueBox", Font: ocr.Dialogue.Name, Lines: []string{frame}}}
+		}
+		err := tr.Observe(texts, nil, "dialogue", start.Add(time.Duration(i)*33*time.Millisecond))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Gets the text of each entry
+func texts(entries []Entry) []string {
+	found := make([]string, len(entries))
+	for i, entry := range entries {
+		found[i] = entry.Text
+	}
+	return found
+}
+
+func TestObserve(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames []string
+		want   []string
+	}{
+		{"typing out", []string{"* Hel", "* Hello,", "* Hello, my child.", "* Hello, my child.", ""},
+			[]string{"* Hello, my child."}},
+		{"misread character", []string{"* Hello, my child.", "* He1lo, my child.", "* Hello, my chi~d.", ""},
+			[]string{"* Hello, my child."}},
+		{"next line", []string{"* Hello, my child.", "* Welcome", "* Welcome to the RUINS!", ""},
+			[]string{"* Hello, my child.", "* Welcome to the RUINS!"}},
+		{"box closed", []string{"* Hello, my child.", "", "* Hello, my child.", ""},
+			[]string{"* Hello, my child.", "* Hello, my child."}},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		tr := New(&out)
+		observe(t, tr, test.frames...)
+		got := texts(tr.Entries())
+		if strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("%s: recorded %q, want %q", test.name, got, test.want)
+		}
+
+		// Every entry is written out as it finishes
+		found, err := Search(&out, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != len(test.want) {
+			t.Errorf("%s: wrote out %v entries, want %v", test.name, len(found), len(test.want))
+		}
+	}
+}
+
+func TestKept(t *testing.T) {
+	defer func(kept int) {
+		Kept = kept
+	}(Kept)
+	Kept = 2
+
+	var out bytes.Buffer
+	tr := New(&out)
+	observe(t, tr, "* One.", "", "* Two.", "", "* Three.", "")
+	got := texts(tr.Entries())
+	if strings.Join(got, "|") != "* Two.|* Three." {
+		t.Errorf("kept %q, want the last two", got)
+	}
+	found, err := Search(&out, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 3 {
+		t.Errorf("wrote out %v entries, want all 3", len(found))
+	}
+}
+
+func TestSearch(t *testing.T) {
+	var out bytes.Buffer
+	tr := New(&out)
+	observe(t, tr, "* Hello, my child.", "", "* Welcome to the RUINS!", "")
+	found, err := Search(&out, "ruins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Text != "* Welcome to the RUINS!" {
+		t.Errorf("found %q searching for ruins", texts(found))
+	}
+}
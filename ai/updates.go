@@ -5,6 +5,7 @@ import (
 	"image"
 	"image/color"
 	"math/rand"
+	"time"
 
 	"github.com/pkg/errors"
 	"gitlab.com/256/Underbot/ai/pathfinding"
@@ -25,47 +26,88 @@ func genUpdate() {
 // BattleMenuUpdate is the function run every frame when the battle menu is detected
 func BattleMenuUpdate(objects []object.Object, win sys.Window, img *image.RGBA) error {
 	genUpdate()
-	recObjects, err := GetWanted(objects, []object.RecognizableObject{
-		object.RecMap["battleOption"],
-		object.RecMap["redHeart"],
-	})
-	if err != nil {
-
-	}
-	recMap := Map(recObjects)
+	CurrentMenu, _ = ReadBattleMenu(objects, Texts, img.Bounds())
 
-	var farthest int
-	var fightOption object.RecognizedObject
-	for i, option := range recMap["battleOption"] {
-		if i == 0 {
-			farthest = option.Parent.ID
-			fightOption = option
-			continue
+	// Spare once it will work, and back out of the MERCY menu until then
+	if mercyShown {
+		key := "x"
+		if CurrentEncounter.Spareable() {
+			key = "z"
 		}
-		if farthest < option.Parent.ID {
-			farthest = option.Parent.ID
-			fightOption = option
+		err := win.Press(key)
+		if err != nil {
+			return errors.Wrap(err, "couldn't press a key in the MERCY menu")
 		}
+		return nil
 	}
 
-	redHeart := rect.RectangleCenter(recMap["redHeart"][0].Parent.Bounds)
-	fightCenter := rect.RectangleCenter(fightOption.Parent.Bounds)
+	// The button stays highlighted while its submenu is open, so the submenu has to be handled first
+	submenu, ok := ReadSubmenu(Texts, heartsIn(objects))
+	if ok {
+		CurrentSubmenu = &submenu
+		itemChosen = time.Time{}
+		return handleSubmenu(win, submenu, CurrentMenu.Selected)
+	}
 
-	if redHeart.X > fightCenter.X {
-		err := win.Press("left")
-		if err != nil {
-			return errors.Wrap(err, "couldn't press left arrow key")
-		}
-	} else {
-		err := win.Press("z")
-		if err != nil {
-			return errors.Wrap(err, "couldn't press z key")
-		}
+	if CurrentMenu.Selected == ButtonNone {
+		return nil
+	}
+	if CurrentMenu.Selected == ButtonItem && !itemChosen.IsZero() && time.Since(itemChosen) > params.NavigateSettle {
+		CurrentEncounter.OutOfItems = true
+		itemChosen = time.Time{}
 	}
 
+	// Heal when the HP is low, spare once it will work, and act to make enemies spareable before fighting them
+	target := ButtonFight
+	enemy, act, peaceful := CurrentEncounter.peacefulAct()
+	switch {
+	case Stats.Low(params.LowHP) && reachable(ButtonItem) && !CurrentEncounter.OutOfItems:
+		target = ButtonItem
+	case CurrentEncounter.Spareable() && reachable(ButtonMercy):
+		target = ButtonMercy
+	case peaceful && reachable(ButtonAct):
+		target = ButtonAct
+	}
+
+	chosen, err := Navigate(win, CurrentMenu.Menu(), int(CurrentMenu.Selected), int(target))
+	if errors.Cause(err) == ErrStuck && target != ButtonFight {
+		// Fall back to the other buttons for the rest of the battle
+		fmt.Printf("Couldn't get to %s, leaving it alone for this battle\n", target)
+		CurrentEncounter.Stuck = append(CurrentEncounter.Stuck, target)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("couldn't get to %s", target))
+	}
+	if !chosen {
+		return nil
+	}
+	switch {
+	case target == ButtonItem && itemChosen.IsZero():
+		itemChosen = time.Now()
+	case target == ButtonAct:
+		// ACT lists the enemies to act on first, and then the acts
+		Select(ButtonAct, enemy, act)
+	}
 	return nil
 }
 
+// Whether a button was found in the battle menu and the cursor hasn't gotten stuck trying to choose it in this battle
+func reachable(button Button) bool {
+	if !CurrentMenu.Found(button) {
+		return false
+	}
+	for _, stuck := range CurrentEncounter.Stuck {
+		if stuck == button {
+			return false
+		}
+	}
+	return true
+}
+
+// When ITEM was last chosen without its list of items showing up yet, or zero if it wasn't
+var itemChosen time.Time
+
 // EmptyUpdate does nothing
 func EmptyUpdate(objects []object.Object, win sys.Window, img *image.RGBA) error {
 	genUpdate()
@@ -75,13 +117,36 @@ func EmptyUpdate(objects []object.Object, win sys.Window, img *image.RGBA) error
 // DialogueUpdate is the function run every frame when dialogue is detected
 func DialogueUpdate(objects []object.Object, win sys.Window, img *image.RGBA) error {
 	genUpdate()
-	err := win.Press("x")
-	if err != nil {
-		return errors.Wrap(err, "failed to press x key")
+	CurrentPrompt = nil
+
+	// Nothing can be answered or advanced until some of the text has been read
+	text := dialogueBoxText(Texts)
+	if text.String() == "" {
+		return nil
 	}
-	err = win.Press("z")
+
+	// Z isn't pressed until the text has finished typing out, as pressing it early confirms a choice before it is read.
+	// X shows the rest of the text at once without choosing anything
+	if !typingFinished(text.String(), time.Now()) {
+		err := win.Press("x")
+		if err != nil {
+			return errors.Wrap(err, "failed to press x key")
+		}
+		return nil
+	}
+
+	prompt, found := FindPrompt(text, heartsIn(objects))
+	if found {
+		CurrentPrompt = &prompt
+		err := answer(win, prompt)
+		if err != nil {
+			return errors.Wrap(err, "failed to answer the prompt")
+		}
+		return nil
+	}
+	err := pressDialogue(win, "z")
 	if err != nil {
-		return errors.Wrap(err, "failed to press z key")
+		return errors.Wrap(err, "failed to advance the dialogue")
 	}
 	return nil
 }
@@ -91,6 +156,12 @@ func InBattleUpdate(objects []object.Object, win sys.Window, img *image.RGBA) er
 	genUpdate()
 	heartObjects, err := GetWanted(objects, append([]object.RecognizableObject{}, object.Hearts...))
 	if len(heartObjects) == 0 {
+		// The heart flashes after being hit, so it vanishing can mean it was hurt
+		detectFlash(objects, time.Now())
+		err = releaseKeys(win)
+		if err != nil {
+			return errors.Wrap(err, "failed to stop dodging")
+		}
 		// Stall until items can be found
 		failedRetrieval++
 		// If stalling takes too long, then try to get unstuck
@@ -102,24 +173,34 @@ func InBattleUpdate(objects []object.Object, win sys.Window, img *image.RGBA) er
 	}
 	failedRetrieval = 0
 
-	// Draw the tiles on the screen
-	tiles, err := pathfinding.MakeTiles(*img)
-	if err != nil {
-		return errors.Wrap(err, "failed to create the screen tiles")
-	}
+	// Find everything that has to be avoided
+	Projectiles = FindProjectiles(objects)
+	drawProjectiles(img, Projectiles)
 
-	// Calculate which tile Frisk is in
+	// Everything about getting hit is decided by the heart's hitbox rather than its whole sprite
+	heart := heartObjects[0].Parent
+	hitbox := HeartHitbox(heart)
+	detectHit(heart, hitbox, Projectiles, Stats.Valid, time.Now())
+
+	// Blue and orange attacks decide whether the heart has to stay still or keep moving
+	CurrentReaction = React(hitbox, Projectiles)
 
-	//currentTile := pathfinding.GetCurrentTile(image.Point{averageX, averageY})
-	//_, err = pathfinding.GetPath(currentTile, pathfinding.GetGoal())
+	// Move the heart to wherever is safest
+	box, ok := fightBox(objects)
+	if !ok {
+		box = heart.Bounds
+	}
+	err = Dodge(win, heart, hitbox, box, Projectiles, CurrentReaction)
 	if err != nil {
-		return errors.Wrap(err, "failed to generate path")
+		return errors.Wrap(err, "failed to dodge")
 	}
-	/*
-		for _, pathTile := range path {
+	drawDodge(img)
 
-		}
-	*/
+	// Draw the tiles on the screen
+	tiles, err := pathfinding.MakeTiles(*img)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the screen tiles")
+	}
 	if GridShow {
 		for _, tile := range tiles {
 			rect.DrawRectangle(img, tile.Color, tile.Rectangle)
@@ -133,6 +214,10 @@ var failedRetrieval int
 // SaveUpdate is the function that presses the save button
 func SaveUpdate(objects []object.Object, win sys.Window, img *image.RGBA) error {
 	genUpdate()
+	if !savedAt.IsZero() {
+		// Already saved, and waiting to dismiss the message
+		return nil
+	}
 	recObjects, err := GetWanted(objects, []object.RecognizableObject{
 		object.RecMap["redHeart"],
 		object.RecMap["saveBox"],
@@ -150,29 +235,43 @@ func SaveUpdate(objects []object.Object, win sys.Window, img *image.RGBA) error
 	failedRetrieval = 0
 	recMap := Map(recObjects)
 
-	// Determines if the heart is on the left side, indicating that it is selecting save
-	if rect.RectangleCenter(recMap["redHeart"][0].Parent.Bounds).X < rect.RectangleCenter(recMap["saveBox"][0].Parent.Bounds).X {
-		err := win.Press("z")
-		if err != nil {
-			return errors.Wrap(err, "failed to press z key")
-		}
-		err = win.Press("z")
-		if err != nil {
-			return errors.Wrap(err, "failed to press z key")
-		}
-	} else {
-		err := win.Press("left")
-		if err != nil {
-			return errors.Wrap(err, "failed to press left key")
-		}
-		err = win.Press("z")
-		if err != nil {
-			return errors.Wrap(err, "failed to press z key")
-		}
-		err = win.Press("z")
-		if err != nil {
-			return errors.Wrap(err, "failed to press z key")
-		}
+	// The options are Save on the left half of the box and Return on the right half
+	box := recMap["saveBox"][0].Parent.Bounds
+	middle := rect.RectangleCenter(box).X
+	menu := Menu{
+		Name:    "save",
+		Options: []image.Rectangle{image.Rect(box.Min.X, box.Min.Y, middle, box.Max.Y), image.Rect(middle, box.Min.Y, box.Max.X, box.Max.Y)},
+		Columns: 2,
+	}
+	cursor := menu.CursorAt(rect.RectangleCenter(recMap["redHeart"][0].Parent.Bounds))
+	chosen, err := Navigate(win, menu, cursor, 0)
+	if errors.Cause(err) == ErrStuck {
+		fmt.Println("The save box isn't responding. Trying to get unstuck...")
+		return unstuck(win)
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to choose to save")
+	}
+	if chosen {
+		savedAt = time.Now()
+	}
+	return nil
+}
+
+// When Save was chosen, or zero if the message saying that the file was saved has been dismissed
+var savedAt time.Time
+
+// Dismisses the message saying that the file was saved once it has had time to show up, as pressing z right after
+// choosing Save could be taken as choosing it again. This is done whatever state the frame is identified as,
+// since the message might not be recognized as the saveScreen
+func dismissSaved(win sys.Window) error {
+	if savedAt.IsZero() || time.Since(savedAt) < params.NavigateSettle {
+		return nil
+	}
+	savedAt = time.Time{}
+	err := win.Press("z")
+	if err != nil {
+		return errors.Wrap(err, "failed to press z key")
 	}
 	return nil
 }
@@ -180,8 +279,8 @@ func SaveUpdate(objects []object.Object, win sys.Window, img *image.RGBA) error
 // OutsideBattleUpdate is the function that pathfinds through the game
 func OutsideBattleUpdate(objects []object.Object, win sys.Window, img *image.RGBA) error {
 	genUpdate()
-	friskObjects, _ := GetWanted(objects, object.Frisk)
-	if len(friskObjects) == 0 {
+	frisk, found := object.FindEntity("frisk", Entities)
+	if !found {
 		// Stall until items can be found
 		failedRetrieval++
 		// If stalling takes too long, then try to get unstuck
@@ -193,20 +292,12 @@ func OutsideBattleUpdate(objects []object.Object, win sys.Window, img *image.RGB
 	}
 	failedRetrieval = 0
 
-	// The point in the middle of all the frisk objects
-	var averageX int
-	var averageY int
-	for _, obj := range friskObjects {
-		point := rect.RectangleCenter(obj.Parent.Bounds)
-		averageX += point.X
-		averageY += point.Y
-	}
-	averageX = averageX / len(friskObjects)
-	averageY = averageY / len(friskObjects)
+	// The point in the middle of Frisk
+	center := rect.RectangleCenter(frisk.Bounds)
 
-	img.Set(averageX, averageY, color.RGBA{255, 0, 0, 255})
-	rect.VLine(img, color.RGBA{255, 0, 0, 255}, averageX, averageY-10, averageY+10)
-	rect.HLine(img, color.RGBA{255, 0, 0, 255}, averageX-10, averageY, averageX+10)
+	img.Set(center.X, center.Y, color.RGBA{255, 0, 0, 255})
+	rect.VLine(img, color.RGBA{255, 0, 0, 255}, center.X, center.Y-10, center.Y+10)
+	rect.HLine(img, color.RGBA{255, 0, 0, 255}, center.X-10, center.Y, center.X+10)
 
 	// Draw the tiles on the screen
 	tiles, err := pathfinding.MakeTiles(*img)
@@ -216,7 +307,7 @@ func OutsideBattleUpdate(objects []object.Object, win sys.Window, img *image.RGB
 
 	// Calculate which tile Frisk is in
 
-	currentTile := pathfinding.GetCurrentTile(image.Point{averageX, averageY})
+	currentTile := pathfinding.GetCurrentTile(center)
 	_, err = pathfinding.GetPath(currentTile, pathfinding.GetGoal())
 	if err != nil {
 		return errors.Wrap(err, "failed to generate path")
@@ -274,7 +365,7 @@ func GetWanted(objects []object.Object, wanted []object.RecognizableObject) ([]o
 	var typesUsed []object.RecognizableObject
 	for _, wantObj := range wanted {
 		for _, obj := range objects {
-			if obj.RecogObj.Type == wantObj {
+			if obj.RecogObj.Type.Is(wantObj) {
 				found = append(found, obj.RecogObj)
 				if !contains(typesUsed, obj.RecogObj.Type) {
 					wantedCompare = append(wantedCompare, obj.RecogObj.Type)
@@ -289,6 +380,19 @@ func GetWanted(objects []object.Object, wanted []object.RecognizableObject) ([]o
 	return found, nil
 }
 
+// GetInside returns the recognized objects of the wanted types that are inside of the container,
+// for example the options inside of the narratorBox
+func GetInside(container object.RecognizedObject, wanted []object.RecognizableObject) []object.RecognizedObject {
+	var found []object.RecognizedObject
+	if container.Parent == nil {
+		return found
+	}
+	for _, obj := range container.Parent.Inside(wanted) {
+		found = append(found, obj.RecogObj)
+	}
+	return found
+}
+
 // Map turns a slice of RecognizableObject into a map that can be searched with the string name
 func Map(recObjects []object.RecognizedObject) (recMap map[string][]object.RecognizedObject) {
 	recMap = make(map[string][]object.RecognizedObject)
@@ -309,7 +413,7 @@ func equal(a, b []object.RecognizableObject) bool {
 		return false
 	}
 	for i, v := range a {
-		if v != b[i] {
+		if !v.Is(b[i]) {
 			return false
 		}
 	}
@@ -318,7 +422,7 @@ func equal(a, b []object.RecognizableObject) bool {
 
 func contains(s []object.RecognizableObject, e object.RecognizableObject) bool {
 	for _, a := range s {
-		if a == e {
+		if a.Is(e) {
 			return true
 		}
 	}
@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"image"
+	"os"
+)
+
+// A window that records the keys pressed, held and released instead of sending them to the game
+type fakeWindow struct {
+	pressed []string
+	held    map[string]bool
+}
+
+func newFakeWindow() *fakeWindow {
+	return &fakeWindow{held: make(map[string]bool)}
+}
+
+func (w *fakeWindow) GetImage() (image.RGBA, error)  { return image.RGBA{}, nil }
+func (w *fakeWindow) Center() (image.Point, error)   { return image.Point{}, nil }
+func (w *fakeWindow) Process() (*os.Process, error)  { return nil, nil }
+func (w *fakeWindow) Name() (string, error)          { return "UNDERTALE", nil }
+func (w *fakeWindow) Resize(width, height int) error { return nil }
+func (w *fakeWindow) SetActive() error               { return nil }
+func (w *fakeWindow) Pause() error                   { return nil }
+func (w *fakeWindow) Resume() error                  { return nil }
+func (w *fakeWindow) WxH() (int, int, error)         { return 640, 480, nil }
+func (w *fakeWindow) ID() (int, error)               { return 1, nil }
+
+func (w *fakeWindow) Press(key string) error {
+	w.pressed = append(w.pressed, key)
+	return nil
+}
+
+func (w *fakeWindow) Hold(key string) error {
+	w.held[key] = true
+	return nil
+}
+
+func (w *fakeWindow) Release(key string) error {
+	delete(w.held, key)
+	return nil
+}
+
+// Gets the keys pressed since the last time, and forgets them
+func (w *fakeWindow) take() []string {
+	pressed := w.pressed
+	w.pressed = nil
+	return pressed
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/ai"
+)
+
+// Holds where the rules for answering prompts are read from.
+// The rules that come with the bot follow Toriel's pacifist route through the RUINS: the pie she asks about doesn't
+// change anything, and asking her how to exit the RUINS from her chair is what moves the story on
+var answersPath = flag.String("answers", "answers.json", "file of the rules for answering the prompts in dialogue")
+
+// HandleAnswers loads the rules for answering prompts. Flags have to be parsed first
+func HandleAnswers() error {
+	file, err := os.Open(*answersPath)
+	if os.IsNotExist(err) {
+		fmt.Printf("There is no %s, so prompts are answered with the %s policy\n", *answersPath, ai.DefaultPolicy)
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to open the answers")
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			panic(errors.Wrap(err, "failed to close the answers"))
+		}
+	}()
+	ai.Answers, ai.DefaultPolicy, err = ai.LoadAnswers(file)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the answers")
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+#include "contour.h"
+
+#include <cstdlib>
+#include <vector>
+
+ContourTree FindContourTree(void* src, int method) {
+    std::vector<std::vector<cv::Point> > contours;
+    std::vector<cv::Vec4i> hierarchy;
+    cv::findContours(*(cv::Mat*)src, contours, hierarchy, cv::RETR_TREE, method);
+
+    size_t total = 0;
+    for (size_t i = 0; i < contours.size(); i++) {
+        total += contours[i].size();
+    }
+
+    // Every array gets at least one element so that none of them are null
+    ContourTree tree;
+    tree.count = (int)contours.size();
+    tree.lengths = (int*)malloc(sizeof(int) * (contours.size() + 1));
+    tree.parents = (int*)malloc(sizeof(int) * (contours.size() + 1));
+    tree.points = (int*)malloc(sizeof(int) * 2 * (total + 1));
+
+    size_t next = 0;
+    for (size_t i = 0; i < contours.size(); i++) {
+        tree.lengths[i] = (int)contours[i].size();
+        // The fourth element of each hierarchy entry is the parent's index
+        tree.parents[i] = hierarchy[i][3];
+        for (size_t j = 0; j < contours[i].size(); j++) {
+            tree.points[next++] = contours[i][j].x;
+            tree.points[next++] = contours[i][j].y;
+        }
+    }
+    return tree;
+}
+
+void ContourTree_Close(ContourTree tree) {
+    free(tree.lengths);
+    free(tree.parents);
+    free(tree.points);
+}
@@ -0,0 +1,51 @@
+// Package contour finds the contours of a thresholded image along with the tree they are nested in.
+// The version of GoCV used throws the hierarchy that OpenCV finds away, so OpenCV is called directly for it
+package contour
+
+/*
+#cgo !windows pkg-config: opencv
+#cgo CXXFLAGS: --std=c++11
+#include <stdlib.h>
+#include "contour.h"
+*/
+import "C"
+
+import (
+	"image"
+	"unsafe"
+
+	"gocv.io/x/gocv"
+)
+
+// FindTree finds the contours of a thresholded image like gocv.FindContours with gocv.RetrievalTree,
+// along with the index of the contour that each one is directly inside of, which is -1 if it isn't inside of any.
+// Like gocv.FindContours, this can modify the image
+func FindTree(src gocv.Mat, method gocv.ContourApproximationMode) (contours [][]image.Point, parents []int) {
+	tree := C.FindContourTree(unsafe.Pointer(src.Ptr()), C.int(method))
+	defer C.ContourTree_Close(tree)
+
+	count := int(tree.count)
+	if count == 0 {
+		return nil, nil
+	}
+	lengths := (*[1 << 28]C.int)(unsafe.Pointer(tree.lengths))[:count:count]
+	treeParents := (*[1 << 28]C.int)(unsafe.Pointer(tree.parents))[:count:count]
+	total := 0
+	for _, length := range lengths {
+		total += int(length)
+	}
+	points := (*[1 << 28]C.int)(unsafe.Pointer(tree.points))[: 2*total : 2*total]
+
+	contours = make([][]image.Point, count)
+	parents = make([]int, count)
+	next := 0
+	for i := range contours {
+		contours[i] = make([]image.Point, int(lengths[i]))
+		for j := range contours[i] {
+			contours[i][j] = image.Point{int(points[next]), int(points[next+1])}
+			next += 2
+		}
+		parents[i] = int(treeParents[i])
+	}
+	return contours, parents
+}
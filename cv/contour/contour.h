@@ -0,0 +1,24 @@
+#ifndef UNDERBOT_CONTOUR_H
+#define UNDERBOT_CONTOUR_H
+
+#ifdef __cplusplus
+#include <opencv2/opencv.hpp>
+extern "C" {
+#endif
+
+// The contours of an image and the tree they are nested in
+typedef struct ContourTree {
+    int count;   // How many contours there are
+    int* lengths; // How many points each contour has
+    int* points;  // The X and Y of every point of every contour, one contour after another
+    int* parents; // The index of the contour each one is directly inside of, or -1
+} ContourTree;
+
+ContourTree FindContourTree(void* src, int method);
+void ContourTree_Close(ContourTree tree);
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif
@@ -1,25 +1,28 @@
 package cv
 
 import (
-	"bytes"
 	"fmt"
 	"image"
 	"image/color"
+	"math"
 	"math/rand"
+	"sort"
 	"time"
 
+	"gitlab.com/256/Underbot/cv/contour"
+	"gitlab.com/256/Underbot/cv/hud"
+	"gitlab.com/256/Underbot/cv/mask"
 	"gitlab.com/256/Underbot/cv/num"
 	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/phash"
 	"gitlab.com/256/Underbot/cv/rect"
-	"gitlab.com/256/Underbot/sys"
+	"gitlab.com/256/Underbot/cv/thresh"
+	"gitlab.com/256/Underbot/cv/track"
 
 	"gitlab.com/256/Underbot/cv/object"
 
-	"gitlab.com/256/Underbot/ai"
-
 	"github.com/pkg/errors"
 	"gocv.io/x/gocv"
-	"golang.org/x/image/bmp"
 )
 
 var (
@@ -30,9 +33,37 @@ var rnd *rand.Rand
 var gray = color.RGBA{193, 193, 193, 255}
 
 var objects []object.Object // A slice of the objects detected in the game
+// The index in objects of the object that each one is directly inside of, or -1
+var parents []int
 // RecognizedObjects is a slice of the detected objects that have been recognized as something
 var RecognizedObjects []object.Object
 
+// Mats that are reused between frames so that they don't have to be reallocated every frame
+var (
+	srcMat   = gocv.NewMat() // The BGR version of the incoming image
+	thresMat = gocv.NewMat() // The thresholded version of srcMat
+)
+
+// The stats read from the HUD row of the last processed frame
+var stats hud.Stats
+
+// Follows the objects across frames
+var tracker = track.NewTracker()
+
+// The name of the AI's current state, which picks the thresholding pipeline
+var aiState string
+
+// SetState tells the CV what state the AI is in, so that the right thresholding pipeline is used for the following frames
+func SetState(name string) {
+	aiState = name
+}
+
+// The regions processed in the last frame
+var lastRegions []image.Rectangle
+
+// Holds repacked pixels for images whose rows aren't tightly packed, reused between frames
+var packBuf []byte
+
 // Fills the slice of random colors up to 300 random colors.
 // This is necessary as random colors can't be generated as quickly on the spot
 func init() {
@@ -60,7 +91,8 @@ func randCol() uint8 {
 	return uint8(rnd.Int31n(255))
 }
 
-// GetObjects returns all the objects' rectangles detected
+// GetObjects returns all the objects' rectangles detected.
+// The slice is replaced rather than modified by the next ProcessImage, so it can be handed to other goroutines
 func GetObjects() []object.Object {
 	return objects
 }
@@ -70,51 +102,335 @@ func GetRecognizedObjects() []object.Object {
 	return RecognizedObjects
 }
 
-// ProcessImage processes image, runs AI code,
+// Snapshot gets copies of the objects and the recognized objects of the last processed frame, linked to each other
+// the same way as the originals. Frames that reuse the objects get these so that no two frames share them
+func Snapshot() ([]object.Object, []object.Object) {
+	copied := make([]object.Object, len(objects))
+	index := make(map[*object.Object]int, len(objects))
+	for i, obj := range objects {
+		index[&objects[i]] = i
+		obj.Motion.History = append([]object.Sample{}, obj.Motion.History...)
+		obj.Candidates = append([]object.Candidate{}, obj.Candidates...)
+		obj.Contour = append([]image.Point{}, obj.Contour...)
+		copied[i] = obj
+	}
+	object.Link(copied, parents)
+
+	recognized := make([]object.Object, len(RecognizedObjects))
+	for i, obj := range RecognizedObjects {
+		at, ok := index[obj.RecogObj.Parent]
+		if !ok {
+			recognized[i] = obj
+			continue
+		}
+		copied[at].RecogObj.Parent = &copied[at]
+		recognized[i] = copied[at]
+	}
+	return copied, recognized
+}
+
+// GetStats returns the stats read from the HUD row of the last processed frame
+func GetStats() hud.Stats {
+	return stats
+}
+
+// ProcessImage finds, recognizes and tracks the objects in image, which was captured at the time given,
 // and then modifies image with debugging information about what the CV sees
-func ProcessImage(img *image.RGBA, win sys.Window) error {
+func ProcessImage(img *image.RGBA, captured time.Time) error {
 	// Converts incoming image into a Mat
-	src, err := imageToMat(*img)
+	err := imageToMat(img, &srcMat)
 	if err != nil {
 		return errors.Wrap(err, "failed to convert the image to a Mat")
 	}
-	defer func() {
-		err := src.Close()
+
+	// Pick the pipeline for the area of the game, which also depends on how dark the room is
+	mean := srcMat.Mean()
+	thresh.Choose(aiState, (mean.Val1+mean.Val2+mean.Val3)/3)
+
+	// Resets the global variables
+	objects = []object.Object{}
+	parents = []int{}
+	RecognizedObjects = []object.Object{}
+
+	// The parts of the frame to look at, which is the whole frame unless the AI is focused on certain areas
+	regions, recognizers := currentFocus(img.Bounds(), object.RecognizableObjects)
+
+	for _, region := range regions {
+		// Find the objects with the pipeline for the current area of the game
+		mainStart := len(objects)
+		err = detect(img, region, thresh.Current(), thresh.Ungrouped(recognizers), true)
 		if err != nil {
-			panic(errors.Wrap(err, "failed to close the orginal Mat"))
+			return errors.Wrap(err, "failed to detect the objects for the current area")
 		}
-	}()
+		mainEnd := len(objects)
+
+		// Groups with their own pipeline only add the objects that they recognize
+		for _, group := range thresh.Groups {
+			err = detect(img, region, group.Pipeline, allowed(group.Recognizers, recognizers), false)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to detect the objects for the %s group", group.Name))
+			}
+		}
+
+		// The groups' contours come from other thresholded images, so they are merged with or put in the tree of the main one
+		mergeGroups(mainStart, mainEnd)
+		for i := mainEnd; i < len(objects); i++ {
+			if parents[i] < 0 {
+				parents[i] = container(objects[i], mainStart, mainEnd)
+			}
+		}
+	}
+
+	// Link the objects into the contour tree so that each object knows what it is inside of.
+	// This has to happen after every object is added, as the links point into the slice
+	object.Link(objects, parents)
+
+	// Follow the objects from the previous frames
+	tracker.Update(objects, regions, captured)
+
+	// Hashes have to be made before the recognized objects are copied out, and before anything is drawn
+	hashObjects(img)
+	collectRecognized()
+
+	// Text has to be read before the debugging information covers it up
+	stats = hud.Read(img, hud.Locate(RecognizedObjects))
+	readTexts(img)
+
+	// Remember the regions processed so they can be drawn again on duplicate frames
+	lastRegions = regions
+	Redraw(img)
+	return nil
+}
+
+// Redraw draws the debugging information about the last processed frame onto image.
+// This is used for frames that are duplicates of the last one, as they aren't processed again
+func Redraw(img *image.RGBA) {
+	drawObjects(img)
+
+	// Show which parts of the frame were processed if the whole frame wasn't
+	if Focused() {
+		for _, region := range lastRegions {
+			rect.DrawRectangle(img, params.FocusColor, region)
+		}
+	}
+}
+
+// A perceptual hash made for a track, along with the size of the object it was made from
+type cachedHash struct {
+	size image.Point
+	hash uint64
+}
+
+// The hashes of the tracks seen in the last processed frame, by their IDs
+var hashes = make(map[int]cachedHash)
+
+// Makes the perceptual hashes of the objects that could be enemy sprites. A track keeps its hash for as long as its size
+// stays the same, so each sprite is only hashed when it shows up instead of every frame
+func hashObjects(img *image.RGBA) {
+	kept := make(map[int]cachedHash)
+	for i := range objects {
+		obj := &objects[i]
+		if !hashable(*obj) {
+			continue
+		}
+		cached, ok := hashes[obj.ID]
+		if !ok || cached.size != obj.Bounds.Size() {
+			cached = cachedHash{obj.Bounds.Size(), phash.Hash(img, obj.Bounds)}
+		}
+		obj.Hash = cached.hash
+		kept[obj.ID] = cached
+	}
+	hashes = kept
+}
+
+// Determines if an object could be an enemy's sprite, which is when it is recognized as an enemy,
+// or when it isn't recognized, isn't inside of anything and is large enough to be told apart by its hash
+func hashable(obj object.Object) bool {
+	if obj.Recognized {
+		for _, enemy := range object.Enemies {
+			if obj.RecogObj.Type.Is(enemy) {
+				return true
+			}
+		}
+		return false
+	}
+	return obj.Parent == nil && obj.Bounds.Dx()*obj.Bounds.Dy() >= params.HashMinArea
+}
+
+// Adds the recognized objects to the global slice of recognized objects
+func collectRecognized() {
+	for i := range objects {
+		obj := &objects[i]
+		if obj.Recognized {
+			// Point the recognition at the object's final place now that the slice won't grow anymore
+			obj.RecogObj.Parent = obj
+			RecognizedObjects = append(RecognizedObjects, *obj)
+		}
+	}
+}
+
+// Thresholds a region of the current frame with the pipeline, and adds the contours found to the global list of objects
+// after trying to recognize them as one of the recognizers.
+// Objects that aren't recognized are only added if keepUnrecognized is true
+func detect(img *image.RGBA, region image.Rectangle, pipeline thresh.Pipeline,
+	recognizers []object.RecognizableObject, keepUnrecognized bool) error {
+	if len(recognizers) == 0 && !keepUnrecognized {
+		return nil
+	}
 
-	// Converts the Mat into a thresholded image
-	thresMat := threshold(src)
+	// Converts the region of the Mat into a thresholded image
+	roi := srcMat.Region(region)
 	defer func() {
-		err := thresMat.Close()
+		err := roi.Close()
 		if err != nil {
-			panic(errors.Wrap(err, "failed to close the threshold Mat"))
+			panic(errors.Wrap(err, "failed to close the region Mat"))
 		}
 	}()
+	err := pipeline.Apply(roi, &thresMat)
+	if err != nil {
+		return errors.Wrap(err, "failed to threshold the region")
+	}
 
-	// Find the contours (individual items on screen)
-	contours := gocv.FindContours(thresMat, gocv.RetrievalTree, gocv.ChainApproxSimple)
+	// Find the contours (individual items on screen) and which of them are inside of which
+	contours, tree := contour.FindTree(thresMat, gocv.ChainApproxSimple)
 
-	// Resets the global variables
-	objects = []object.Object{}
-	RecognizedObjects = []object.Object{}
-
-	// A secondary iterator that only iterates each time a random color is used.
-	// This is to prevent unneeded extra colors from being created
-	usedColors := 0
+	// The index in objects of the object made from each contour, or -1 if it wasn't added
+	added := make([]int, len(contours))
 
 	// Iterate through the detected objects (literal objects, not the ones in the object package yet)
-	for i, obj := range contours {
+	for i, contour := range contours {
 		// Generates more random colors if needed
-		if (len(obj) > len(colors)) && params.Coloring == 1 {
+		if (len(contour) > len(colors)) && params.Coloring == 1 {
 			addToColor()
 		}
 
-		// Gets surrounding rectangle of object
-		rec := rect.GetRectangle(obj)
+		// Gets surrounding rectangle of object, moving it from the region's coordinates to the frame's
+		rec := rect.GetRectangle(contour).Add(region.Min)
+
+		// The color of the object detected
+		objColor, err := rect.CenterColor(img.SubImage(rec))
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("could not detect the center color of object %v", i))
+		}
+		// Create new object instance to be build upon
+		obj := object.Object{Bounds: rec, ID: len(objects) + 1, Color: objColor, Recognized: false,
+			RecogObj: object.RecognizedObject{}, Contour: offsetContour(contour, region.Min)}
+		obj.EdgeColor = rect.EdgeColor(img, obj.Contour)
+
+		// Determine if the object is a RecognizableObject, and sets the proper field values
+		err = recognize(&obj, recognizers)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed in recognizing object %v", i))
+		}
 
+		// Add the object to the global list of objects
+		added[i] = -1
+		if obj.Recognized || keepUnrecognized {
+			added[i] = len(objects)
+			objects = append(objects, obj)
+			parents = append(parents, -1)
+		}
+	}
+
+	// Each object is inside of the object made from the closest of its contour's ancestors that was added
+	for i, index := range added {
+		if index < 0 {
+			continue
+		}
+		for parent := tree[i]; parent >= 0; parent = tree[parent] {
+			if added[parent] >= 0 {
+				parents[index] = added[parent]
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// Merges the objects recognized by the groups' pipelines, which come after mainEnd, into the unrecognized objects that
+// the main pipeline found between mainStart and mainEnd for the same sprite. Otherwise every sprite in a group would be
+// there twice, once as what it is and once as an unknown object
+func mergeGroups(mainStart, mainEnd int) {
+	// Where each group object ends up, as removing the merged ones moves the rest
+	moved := make([]int, len(objects)-mainEnd)
+	kept, keptParents := objects[:mainEnd], parents[:mainEnd]
+	for i := mainEnd; i < len(objects); i++ {
+		same := sameObject(objects[i], mainStart, mainEnd)
+		if same >= 0 {
+			objects[same].Recognized = true
+			objects[same].RecogObj = objects[i].RecogObj
+			objects[same].Candidates = objects[i].Candidates
+			moved[i-mainEnd] = same
+			continue
+		}
+		moved[i-mainEnd] = len(kept)
+		kept = append(kept, objects[i])
+		keptParents = append(keptParents, parents[i])
+	}
+	for i := mainEnd; i < len(kept); i++ {
+		if keptParents[i] >= mainEnd {
+			keptParents[i] = moved[keptParents[i]-mainEnd]
+		}
+	}
+	objects, parents = kept, keptParents
+}
+
+// Finds the unrecognized object in the range given that covers the same area as the object, or returns -1
+func sameObject(obj object.Object, start, end int) int {
+	for i := start; i < end; i++ {
+		if !objects[i].Recognized && overlap(obj.Bounds, objects[i].Bounds) >= params.GroupMergeOverlap {
+			return i
+		}
+	}
+	return -1
+}
+
+// Gets the area two rectangles share divided by the area they cover together
+func overlap(a, b image.Rectangle) float64 {
+	shared := a.Intersect(b)
+	sharedArea := shared.Dx() * shared.Dy()
+	union := a.Dx()*a.Dy() + b.Dx()*b.Dy() - sharedArea
+	if union <= 0 {
+		return 0
+	}
+	return float64(sharedArea) / float64(union)
+}
+
+// Finds the smallest of the objects in the range given whose contour the object is inside of,
+// and returns its index or -1 if there isn't one. Contours never cross, so checking a single point is enough
+func container(obj object.Object, start, end int) int {
+	found := -1
+	for i := start; i < end; i++ {
+		outer := objects[i]
+		if len(obj.Contour) == 0 || !obj.Bounds.In(outer.Bounds) || obj.Bounds == outer.Bounds {
+			continue
+		}
+		if !mask.Inside(outer.Contour, obj.Contour[0]) {
+			continue
+		}
+		if found < 0 || outer.Bounds.Dx()*outer.Bounds.Dy() < objects[found].Bounds.Dx()*objects[found].Bounds.Dy() {
+			found = i
+		}
+	}
+	return found
+}
+
+// Moves a contour from a region's coordinates to the frame's, copying it so that the contour isn't shared
+func offsetContour(contour []image.Point, offset image.Point) []image.Point {
+	moved := make([]image.Point, len(contour))
+	for i, point := range contour {
+		moved[i] = point.Add(offset)
+	}
+	return moved
+}
+
+// Draws a rectangle around every object
+func drawObjects(img *image.RGBA) {
+	// A secondary iterator that only iterates each time a random color is used.
+	// This is to prevent unneeded extra colors from being created
+	usedColors := 0
+
+	for _, obj := range objects {
 		// The color the surrounding rectangle should have
 		var dispColor color.Color
 
@@ -128,20 +444,6 @@ func ProcessImage(img *image.RGBA, win sys.Window) error {
 			dispColor = gray
 		}
 
-		// The color of the object detected
-		objColor, err := rect.CenterColor(img.SubImage(rec))
-		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("could not detect the center color of object %v", i))
-		}
-		// Create new object instance to be build upon
-		obj := object.Object{Bounds: rec, ID: i + 1, Color: objColor, Recognized: false, RecogObj: object.RecognizedObject{}}
-
-		// Determine if the object is a RecognizableObject, and sets the proper field values
-		err = recognize(&obj)
-		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed in recognizing object %v", i))
-		}
-
 		// Change the coloring if the object is recognized
 		if obj.Recognized {
 			// If the object's recognition is black, then give it a random color instead
@@ -155,15 +457,7 @@ func ProcessImage(img *image.RGBA, win sys.Window) error {
 
 		// Draw a rectangle around the object
 		rect.DrawObject(img, dispColor, obj)
-
-		// Add the object to the global list of objects
-		objects = append(objects, obj)
 	}
-	err = ai.Handle(objects, RecognizedObjects, win, img)
-	if err != nil {
-		return errors.Wrap(err, "ai failed to act upon the objects")
-	}
-	return nil
 }
 
 // Determines if the color input is black
@@ -171,64 +465,58 @@ func isBlack(col color.Color) bool {
 	return (col == color.RGBA{0, 0, 0, 255})
 }
 
-// Converts an image into a thresholded black and white Mat
-func threshold(src gocv.Mat) gocv.Mat {
-	// Placeholder for colorless original Mat
-	srcGray := gocv.NewMat()
+// Converts an image into a BGR GoCV Mat, placing the result in dst.
+// The pixel buffer is handed to OpenCV directly instead of being encoded and decoded
+func imageToMat(img *image.RGBA, dst *gocv.Mat) error {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return errors.New("the image is empty")
+	}
+
+	rgba, err := gocv.NewMatFromBytes(bounds.Dy(), bounds.Dx(), gocv.MatTypeCV8UC4, packPixels(img))
+	if err != nil {
+		return errors.Wrap(err, "failed to create a Mat from the pixel buffer")
+	}
 	defer func() {
-		err := srcGray.Close()
+		err := rgba.Close()
 		if err != nil {
-			panic(errors.Wrap(err, "failed to close the gray Mat"))
+			panic(errors.Wrap(err, "failed to close the RGBA Mat"))
 		}
 	}()
 
-	// Put original image into srcGray without color
-	gocv.CvtColor(src, &srcGray, gocv.ColorBGRToGray)
-
-	// Places threshold onto the image
-	thresMat := gocv.NewMat()
-	gocv.Threshold(srcGray, &thresMat, 50, 255, gocv.ThresholdBinary)
-
-	return thresMat
+	// OpenCV works with BGR, so drop the alpha channel and swap red and blue
+	gocv.CvtColor(rgba, dst, gocv.ColorRGBAToBGR)
+	return nil
 }
 
-// Converts an image into a GoCV mat
-func imageToMat(img image.RGBA) (gocv.Mat, error) {
-	bmp, err := imageToBmp(img)
-	if err != nil {
-		return gocv.Mat{}, errors.Wrap(err, "failed to convert the image to a BMP")
+// Returns the pixels of an image with no padding between rows, as OpenCV expects.
+// Images that are already tightly packed (such as window captures) are returned without copying
+func packPixels(img *image.RGBA) []byte {
+	bounds := img.Bounds()
+	rowLen := 4 * bounds.Dx()
+	if img.Stride == rowLen && len(img.Pix) >= rowLen*bounds.Dy() {
+		return img.Pix[:rowLen*bounds.Dy()]
 	}
-	src, err := gocv.IMDecode(bmp, 1)
-	if err != nil {
-		return gocv.Mat{}, errors.Wrap(err, "Failed to decode incoming image for OpenCV")
-	}
-	return src, nil
-}
 
-// Converts image to a slice of bytes representing the bitmap encoding of the image
-func imageToBmp(img image.RGBA) ([]byte, error) {
-	// Placeholder for the bytes from the encoding to go to
-	buf := new(bytes.Buffer)
-
-	// Fill the buffer with the bitmap encoding
-	err := bmp.Encode(buf, &img)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to encode image to bitmap format")
+	if cap(packBuf) < rowLen*bounds.Dy() {
+		packBuf = make([]byte, rowLen*bounds.Dy())
 	}
-	return buf.Bytes(), nil
+	packBuf = packBuf[:rowLen*bounds.Dy()]
+	for y := 0; y < bounds.Dy(); y++ {
+		copy(packBuf[y*rowLen:(y+1)*rowLen], img.Pix[y*img.Stride:y*img.Stride+rowLen])
+	}
+	return packBuf
 }
 
 // Function handling the actions that should be taken if an object is recognized
 func recTreatment(obj *object.Object, recogObj object.RecognizedObject) {
 	obj.Recognized = true
 	obj.RecogObj = recogObj
-
-	// Add object to the global slice of recognized objects
-	RecognizedObjects = append(RecognizedObjects, *obj)
 }
 
-// Determines if an object is a RecognizableObject, and take action if so
-func recognize(obj *object.Object) error {
+// Determines which of the recognizers an object could be and ranks them by how well they match.
+// The object is recognized as the best candidate if its score is at least params.MinScore
+func recognize(obj *object.Object, recognizers []object.RecognizableObject) error {
 	err := obj.Check()
 	if err != nil {
 		return errors.Wrap(err, "refusing to operate on invalid object")
@@ -237,29 +525,72 @@ func recognize(obj *object.Object) error {
 	size := image.Point{obj.Bounds.Dx(), obj.Bounds.Dy()}
 
 	// Iterate over the possible recognizable objects
-	for _, recogObj := range object.RecognizableObjects {
-		var leniance int
-		if recogObj.Leniance == -1 {
-			leniance = params.Leniance
-		} else {
-			leniance = recogObj.Leniance
+	obj.Candidates = nil
+	for _, recogObj := range recognizers {
+		score := matchScore(obj, size, recogObj)
+		if score > 0 {
+			obj.Candidates = append(obj.Candidates, object.Candidate{Type: recogObj, Score: score})
 		}
-		if !isBlack(recogObj.Color) { // If the object is colored properly, then check for size and coloring equality
-			if num.PntWithin(recogObj.Size, size, leniance) && recogObj.Color == obj.Color {
-				recognized, err := object.NewRecognizedObject(obj, recogObj)
-				if err != nil {
-					return errors.Wrap(err, "failed to create new recognized object")
-				}
-				recTreatment(obj, recognized)
-			}
-			// If the recognized object is black, then only check for size
-		} else if num.PntWithin(recogObj.Size, size, leniance) {
-			recognized, err := object.NewRecognizedObject(obj, recogObj)
-			if err != nil {
-				return errors.Wrap(err, "failed to create new recognized object")
+	}
+	sort.SliceStable(obj.Candidates, func(i, j int) bool {
+		return obj.Candidates[i].Score > obj.Candidates[j].Score
+	})
+	obj.Candidates = bestFrames(obj.Candidates)
+
+	if len(obj.Candidates) == 0 || obj.Candidates[0].Score < params.MinScore {
+		return nil
+	}
+	best := obj.Candidates[0]
+	recognized, err := object.NewRecognizedObject(obj, best.Type)
+	if err != nil {
+		return errors.Wrap(err, "failed to create new recognized object")
+	}
+	recognized.Score = best.Score
+	recTreatment(obj, recognized)
+	return nil
+}
+
+// Removes all but the best frame of each animation from the sorted candidates,
+// so that frames of the same animation aren't considered different things
+func bestFrames(candidates []object.Candidate) []object.Candidate {
+	var kept []object.Candidate
+	for _, cand := range candidates {
+		duplicate := false
+		for _, keep := range kept {
+			if keep.Type.Is(cand.Type) {
+				duplicate = true
+				break
 			}
-			recTreatment(obj, recognized)
+		}
+		if !duplicate {
+			kept = append(kept, cand)
 		}
 	}
-	return nil
+	return kept
+}
+
+// Scores how well an object matches a recognizer, from 0 (not at all) to 1 (exactly)
+func matchScore(obj *object.Object, size image.Point, recogObj object.RecognizableObject) float64 {
+	var leniance int
+	if recogObj.Leniance == -1 {
+		leniance = params.Leniance
+	} else {
+		leniance = recogObj.Leniance
+	}
+	if !num.PntWithin(recogObj.Size, size, leniance) {
+		return 0
+	}
+	// The further off the size is, the lower the score
+	diff := math.Abs(float64(recogObj.Size.X-size.X)) + math.Abs(float64(recogObj.Size.Y-size.Y))
+	score := 1 - diff/float64(2*(leniance+1))
+
+	// If the recognized object is black, then only the size is checked
+	if isBlack(recogObj.Color) {
+		return score * params.SizeOnlyWeight
+	}
+	// Otherwise the coloring has to be equal as well
+	if recogObj.Color != obj.Color {
+		return 0
+	}
+	return score
 }
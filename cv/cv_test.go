@@ -0,0 +1,250 @@
+package cv
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/thresh"
+	"gocv.io/x/gocv"
+	"golang.org/x/image/bmp"
+)
+
+// Creates an image the size of the resized Undertale window filled with a pattern of colors
+func benchImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), uint8(x + y), 255})
+		}
+	}
+	return img
+}
+
+// The previous conversion, which encodes the image to a BMP in memory and decodes it with OpenCV
+func bmpImageToMat(img *image.RGBA) (gocv.Mat, error) {
+	buf := new(bytes.Buffer)
+	err := bmp.Encode(buf, img)
+	if err != nil {
+		return gocv.Mat{}, err
+	}
+	return gocv.IMDecode(buf.Bytes(), 1)
+}
+
+// TestImageToMat checks that the direct conversion gives the same BGR pixels as the BMP round-trip,
+// both for a tightly packed image and for a sub-image whose rows are padded
+func TestImageToMat(t *testing.T) {
+	full := benchImage()
+	images := map[string]*image.RGBA{
+		"packed": full,
+		"padded": full.SubImage(image.Rect(13, 7, 301, 199)).(*image.RGBA),
+	}
+	for name, img := range images {
+		want, err := bmpImageToMat(img)
+		if err != nil {
+			t.Fatalf("%s: failed to convert through a BMP: %v", name, err)
+		}
+		got := gocv.NewMat()
+		err = imageToMat(img, &got)
+		if err != nil {
+			t.Fatalf("%s: failed to convert directly: %v", name, err)
+		}
+		if got.Rows() != want.Rows() || got.Cols() != want.Cols() || got.Channels() != want.Channels() {
+			t.Errorf("%s: got a %vx%vx%v Mat, want %vx%vx%v", name,
+				got.Cols(), got.Rows(), got.Channels(), want.Cols(), want.Rows(), want.Channels())
+		} else if !bytes.Equal(got.ToBytes(), want.ToBytes()) {
+			t.Errorf("%s: the pixels differ from the BMP round-trip", name)
+		}
+		want.Close()
+		got.Close()
+	}
+}
+
+// BenchmarkImageToMatBmp measures the BMP encode/decode round-trip
+func BenchmarkImageToMatBmp(b *testing.B) {
+	img := benchImage()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mat, err := bmpImageToMat(img)
+		if err != nil {
+			b.Fatal(err)
+		}
+		err = mat.Close()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkImageToMat measures the direct pixel buffer conversion into a reused Mat
+func BenchmarkImageToMat(b *testing.B) {
+	img := benchImage()
+	dst := gocv.NewMat()
+	defer dst.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := imageToMat(img, &dst)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkThreshold measures thresholding a converted frame into a reused Mat with each area's pipeline
+func BenchmarkThreshold(b *testing.B) {
+	img := benchImage()
+	src := gocv.NewMat()
+	defer src.Close()
+	dst := gocv.NewMat()
+	defer dst.Close()
+	err := imageToMat(img, &src)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, pipeline := range thresh.Areas {
+		pipeline := pipeline
+		b.Run(pipeline.Name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				err := pipeline.Apply(src, &dst)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkReadTexts measures reading the text boxes of a frame, which happens every frame and has to fit in its 33ms.
+// The narratorBox is in Sans, which is the slowest case as the fonts before it are tried first
+func BenchmarkReadTexts(b *testing.B) {
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	dialogue := image.Rect(32, 10, 609, 161)
+	narrator := image.Rect(33, 250, 607, 389)
+	white := color.RGBA{255, 255, 255, 255}
+	ocr.Draw(img, dialogue.Min.Add(image.Point{30, 20}), ocr.Dialogue, "* Hello, my child.\n* Welcome to the RUINS!", white)
+	ocr.Draw(img, narrator.Min.Add(image.Point{30, 20}), ocr.Sans, "* heya.\n* you've been busy, huh?", white)
+	RecognizedObjects = []object.Object{
+		{Bounds: dialogue, Recognized: true, RecogObj: object.RecognizedObject{Type: object.RecMap["dialogueBox"]}},
+		{Bounds: narrator, Recognized: true, RecogObj: object.RecognizedObject{Type: object.RecMap["narratorBox"]}},
+	}
+	defer func() {
+		RecognizedObjects = nil
+	}()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		readTexts(img)
+	}
+}
+
+func TestHashObjects(t *testing.T) {
+	defer func() {
+		objects = nil
+		hashes = make(map[int]cachedHash)
+	}()
+	// A checkered pattern, as a smooth gradient can hash to 0
+	img := benchImage()
+	for y := 0; y < 480; y++ {
+		for x := 0; x < 640; x++ {
+			if (x/9+y/7)%2 == 0 {
+				img.Set(x, y, color.RGBA{255, 255, 255, 255})
+			}
+		}
+	}
+	box := object.Object{ID: 1, Bounds: image.Rect(33, 250, 607, 389), Recognized: true,
+		RecogObj: object.RecognizedObject{Type: object.RecMap["narratorBox"]}}
+	sprite := object.Object{ID: 2, Bounds: image.Rect(280, 60, 360, 200)}
+	inside := object.Object{ID: 3, Bounds: image.Rect(300, 80, 340, 120), Parent: &sprite}
+	dummy := object.Object{ID: 4, Bounds: image.Rect(100, 100, 127, 119), Recognized: true,
+		RecogObj: object.RecognizedObject{Type: object.RecMap["dummy"]}}
+	small := object.Object{ID: 5, Bounds: image.Rect(10, 10, 15, 15)}
+	objects = []object.Object{box, sprite, inside, dummy, small}
+	hashObjects(img)
+	for i, want := range []bool{false, true, false, true, false} {
+		if hashed := objects[i].Hash != 0; hashed != want {
+			t.Errorf("object %v was hashed: %v, want %v", objects[i].ID, hashed, want)
+		}
+	}
+	first := objects[1].Hash
+
+	// The same track keeps its hash while its size is the same, even if what it looks like changes
+	for y := 60; y < 200; y++ {
+		for x := 280; x < 360; x++ {
+			img.Set(x, y, color.RGBA{uint8(y * 3), uint8(x * 7), 0, 255})
+		}
+	}
+	objects = []object.Object{sprite}
+	hashObjects(img)
+	if objects[0].Hash != first {
+		t.Errorf("the sprite was hashed again without changing size")
+	}
+	objects = []object.Object{{ID: 2, Bounds: image.Rect(280, 60, 360, 190)}}
+	hashObjects(img)
+	if objects[0].Hash == first {
+		t.Errorf("the sprite wasn't hashed again after changing size")
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	defer func() {
+		objects = nil
+		parents = nil
+		RecognizedObjects = nil
+	}()
+	objects = []object.Object{
+		{ID: 1, Bounds: image.Rect(0, 0, 100, 100), Contour: []image.Point{{0, 0}, {99, 99}}},
+		{ID: 2, Bounds: image.Rect(10, 10, 20, 20), Recognized: true, RecogObj: object.RecognizedObject{Type: object.RecMap["redHeart"]},
+			Motion: object.Motion{History: []object.Sample{{Bounds: image.Rect(10, 10, 20, 20)}}}},
+	}
+	parents = []int{-1, 0}
+	object.Link(objects, parents)
+	collectRecognized()
+
+	copied, recognized := Snapshot()
+	if copied[1].Parent != &copied[0] || len(copied[0].Children) != 1 || copied[0].Children[0] != &copied[1] {
+		t.Errorf("the copies aren't linked to each other")
+	}
+	if len(recognized) != 1 || recognized[0].RecogObj.Parent != &copied[1] {
+		t.Errorf("the recognized copy doesn't point at the copied object")
+	}
+
+	// Changing the copies leaves the originals alone
+	copied[0].Contour[0] = image.Point{5, 5}
+	copied[1].Motion.History[0].Bounds = image.Rectangle{}
+	if objects[0].Contour[0] != (image.Point{}) || objects[1].Motion.History[0].Bounds.Empty() {
+		t.Errorf("the copies share their contents with the originals")
+	}
+}
+
+func TestIsDuplicate(t *testing.T) {
+	defer func() {
+		focusRegions = nil
+		lastHash = 0
+	}()
+	// A heart moving a pixel between frames, which falls in between the pixels sampled from the whole frame
+	frame := func(x int) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+		for y := 301; y < 303; y++ {
+			img.Set(x, y, color.RGBA{255, 0, 0, 255})
+		}
+		return img
+	}
+	box := image.Rect(250, 250, 390, 390)
+
+	for _, test := range []struct {
+		name  string
+		focus []image.Rectangle
+		want  bool
+	}{
+		{"whole frame", nil, true},
+		{"focused on the fightBox", []image.Rectangle{box}, false},
+	} {
+		focusRegions = test.focus
+		IsDuplicate(frame(301))
+		if got := IsDuplicate(frame(302)); got != test.want {
+			t.Errorf("%s: moving a pixel was a duplicate: %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package cv
+
+import (
+	"hash/fnv"
+	"image"
+
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+// The hash of the last frame that wasn't a duplicate
+var lastHash uint64
+
+// IsDuplicate determines if an image is the same as the last image given that wasn't a duplicate.
+// The game only draws 30 frames a second, so most captures are repeats that don't need to be processed again.
+// Only every params.DiffStep pixel in each direction is compared to keep this cheap, except in the regions the AI is
+// focused on, where every pixel is compared so that things moving a pixel or two, like the heart and slow bullets, aren't missed.
+// This should be called before the image is drawn on
+func IsDuplicate(img *image.RGBA) bool {
+	hash := sampleHash(img, focusRegions)
+	if hash == lastHash {
+		return true
+	}
+	lastHash = hash
+	return false
+}
+
+// Hashes the pixels of a downsampled version of the image, along with every pixel of the regions given
+func sampleHash(img *image.RGBA, full []image.Rectangle) uint64 {
+	hasher := fnv.New64a()
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += params.DiffStep {
+		for x := bounds.Min.X; x < bounds.Max.X; x += params.DiffStep {
+			offset := img.PixOffset(x, y)
+			// Only the color matters, not the alpha. Writing to a hash never fails
+			hasher.Write(img.Pix[offset : offset+3])
+		}
+	}
+	for _, region := range full {
+		region = region.Intersect(bounds)
+		if region.Empty() {
+			continue
+		}
+		for y := region.Min.Y; y < region.Max.Y; y++ {
+			hasher.Write(img.Pix[img.PixOffset(region.Min.X, y):img.PixOffset(region.Max.X, y)])
+		}
+	}
+	return hasher.Sum64()
+}
@@ -0,0 +1,85 @@
+package cv
+
+import (
+	"image"
+
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+// The regions of interest and recognizers set by the AI. If there are no regions, the whole frame is processed
+var focusRegions []image.Rectangle
+var focusRecognizers []object.RecognizableObject
+
+// How many frames have been processed since the whole frame was last processed
+var framesSinceFull int
+
+// Whether the last frame processed only looked at the regions of interest
+var lastFocused bool
+
+// SetFocus limits the processing of the following frames to the regions given, only using the recognizers given.
+// Giving no regions goes back to processing the whole frame with every recognizer
+func SetFocus(regions []image.Rectangle, recognizers []object.RecognizableObject) {
+	focusRegions = mergeRegions(regions)
+	focusRecognizers = recognizers
+}
+
+// Focused determines if the last frame processed only looked at the regions of interest
+func Focused() bool {
+	return lastFocused
+}
+
+// Gets the regions of the frame to process and the recognizers to use in them.
+// The whole frame is still processed every so often so that changes outside of the regions are noticed
+func currentFocus(bounds image.Rectangle, recognizers []object.RecognizableObject) ([]image.Rectangle,
+	[]object.RecognizableObject) {
+	framesSinceFull++
+	if len(focusRegions) == 0 || framesSinceFull >= params.FullFrameInterval {
+		framesSinceFull = 0
+		lastFocused = false
+		return []image.Rectangle{bounds}, recognizers
+	}
+	lastFocused = true
+
+	var regions []image.Rectangle
+	for _, region := range focusRegions {
+		region = region.Intersect(bounds)
+		if !region.Empty() {
+			regions = append(regions, region)
+		}
+	}
+	return regions, allowed(recognizers, focusRecognizers)
+}
+
+// Returns the recognizers that are also in the allowed list. A nil allowed list allows every recognizer
+func allowed(recognizers []object.RecognizableObject, allowed []object.RecognizableObject) []object.RecognizableObject {
+	if allowed == nil {
+		return recognizers
+	}
+	var kept []object.RecognizableObject
+	for _, recogObj := range recognizers {
+		for _, allow := range allowed {
+			if recogObj.Is(allow) {
+				kept = append(kept, recogObj)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// Combines overlapping regions so that no part of the frame is processed twice
+func mergeRegions(regions []image.Rectangle) []image.Rectangle {
+	merged := append([]image.Rectangle{}, regions...)
+	for i := 0; i < len(merged); i++ {
+		for j := i + 1; j < len(merged); j++ {
+			if merged[i].Overlaps(merged[j]) {
+				merged[i] = merged[i].Union(merged[j])
+				merged = append(merged[:j], merged[j+1:]...)
+				// The grown region might now overlap regions that were already checked
+				j = i
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,106 @@
+// Package hud reads the player's stats from the HUD row shown below the box during a battle
+package hud
+
+import (
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+// Stats are the values shown in the HUD row
+type Stats struct {
+	LV    int
+	HP    int
+	MaxHP int
+	Bar   float64 // How full the HP bar is, from 0 to 1. This is still known when the numbers can't be read
+	Valid bool    // Whether the numbers were read. Outside of battles there is no HUD, so this is false
+	Text  string  // Everything that was read from the row, for debugging
+}
+
+// Low determines if the HP is at or below the fraction of the max HP given
+func (s Stats) Low(fraction float64) bool {
+	if s.Valid && s.MaxHP > 0 {
+		return float64(s.HP) <= fraction*float64(s.MaxHP)
+	}
+	return s.Bar > 0 && s.Bar <= fraction
+}
+
+var (
+	lvPattern = regexp.MustCompile(`LV(\d+)`)
+	hpPattern = regexp.MustCompile(`(\d+)/(\d+)`)
+)
+
+// Locate finds where the HUD row is from the battle buttons, which it is always straight above.
+// Frames that are shifted down, such as by the window's title bar or letterboxing, still have it found.
+// If none of the buttons were recognized, params.HUDRegion is used
+func Locate(recognized []object.Object) image.Rectangle {
+	top := -1
+	for _, obj := range recognized {
+		if obj.RecogObj.Type.Is(object.RecMap["battleOption"]) && (top < 0 || obj.Bounds.Min.Y < top) {
+			top = obj.Bounds.Min.Y
+		}
+	}
+	if top < 0 {
+		return params.HUDRegion
+	}
+	bottom := top - params.HUDAboveButtons
+	return image.Rect(params.HUDRegion.Min.X, bottom-params.HUDRegion.Dy(), params.HUDRegion.Max.X, bottom)
+}
+
+// Read reads the HUD row inside of a region of a frame. This has to be done before anything is drawn on the frame
+func Read(img *image.RGBA, region image.Rectangle) Stats {
+	region = region.Intersect(img.Bounds())
+	if region.Empty() {
+		return Stats{}
+	}
+	stats := Stats{Bar: bar(img, region)}
+
+	// Spaces are dropped, as the gaps around the numbers are different depending on how many digits there are
+	stats.Text = ocr.ReadString(img, region, ocr.HUD, ocr.Bright(params.TextBrightness))
+	text := strings.Replace(stats.Text, " ", "", -1)
+	lv := lvPattern.FindStringSubmatch(text)
+	hp := hpPattern.FindStringSubmatch(text)
+	if lv == nil || hp == nil {
+		return stats
+	}
+	stats.LV, _ = strconv.Atoi(lv[1])
+	stats.HP, _ = strconv.Atoi(hp[1])
+	stats.MaxHP, _ = strconv.Atoi(hp[2])
+	stats.Valid = stats.MaxHP > 0 && stats.HP <= stats.MaxHP
+	return stats
+}
+
+// Measures how full the HP bar is. The filled part is yellow and the empty part is red
+func bar(img *image.RGBA, region image.Rectangle) float64 {
+	yellow, red := 0, 0
+	y := (region.Min.Y + region.Max.Y) / 2
+	for x := region.Min.X; x < region.Max.X; x++ {
+		switch col := img.RGBAAt(x, y); {
+		case Yellow(col):
+			yellow++
+		case isRed(col):
+			red++
+		}
+	}
+	if yellow+red == 0 {
+		return 0
+	}
+	return float64(yellow) / float64(yellow+red)
+}
+
+// Yellow determines if a color is the yellow of the filled part of the HP bar.
+// The game uses the same yellow for the highlighted battle button and the names of enemies that can be spared
+func Yellow(col color.RGBA) bool {
+	return col.R > 200 && col.G > 200 && col.B < 100
+}
+
+// Determines if a color is the red of the empty part of the HP bar
+func isRed(col color.RGBA) bool {
+	return col.R > 200 && col.G < 60 && col.B < 60
+}
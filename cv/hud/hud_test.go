@@ -0,0 +1,118 @@
+package hud
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+var (
+	white  = color.RGBA{255, 255, 255, 255}
+	yellow = color.RGBA{255, 255, 0, 255}
+	red    = color.RGBA{255, 0, 0, 255}
+)
+
+// Draws a HUD row the way the game lays it out, with the filled part of the HP bar taking up the fraction given.
+// The text is drawn with the same glyphs that read it, so this checks the layout and the parsing of the stats
+// rather than reading the game's HUD, which is up to the crops of real screenshots read by the ocr package's TestScreenshots
+func drawHUD(region image.Rectangle, lv, hp string, filled float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	top := region.Min.Y + 5
+	ocr.Draw(img, image.Point{region.Min.X + 100, top}, ocr.HUD, "LV "+lv, white)
+	ocr.Draw(img, image.Point{region.Min.X + 215, top}, ocr.HUD, "HP", white)
+	bar := image.Rect(region.Min.X+245, region.Min.Y+3, region.Min.X+270, region.Max.Y-3)
+	split := bar.Min.X + int(filled*float64(bar.Dx()))
+	for y := bar.Min.Y; y < bar.Max.Y; y++ {
+		for x := bar.Min.X; x < bar.Max.X; x++ {
+			if x < split {
+				img.SetRGBA(x, y, yellow)
+			} else {
+				img.SetRGBA(x, y, red)
+			}
+		}
+	}
+	ocr.Draw(img, image.Point{region.Min.X + 285, top}, ocr.HUD, hp, white)
+	return img
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		lv, hp    string
+		filled    float64
+		want      Stats
+		wantValid bool
+	}{
+		{"1", "20 / 20", 1, Stats{LV: 1, HP: 20, MaxHP: 20}, true},
+		{"12", "5 / 68", 0.08, Stats{LV: 12, HP: 5, MaxHP: 68}, true},
+		// More HP than the max is a misread
+		{"3", "40 / 28", 1, Stats{}, false},
+	}
+	for _, test := range tests {
+		img := drawHUD(params.HUDRegion, test.lv, test.hp, test.filled)
+		stats := Read(img, params.HUDRegion)
+		if stats.Valid != test.wantValid {
+			t.Errorf("read %q as valid %v, want %v", stats.Text, stats.Valid, test.wantValid)
+			continue
+		}
+		if test.wantValid && (stats.LV != test.want.LV || stats.HP != test.want.HP || stats.MaxHP != test.want.MaxHP) {
+			t.Errorf("read %q as LV %v HP %v/%v, want LV %v HP %v/%v", stats.Text, stats.LV, stats.HP, stats.MaxHP,
+				test.want.LV, test.want.HP, test.want.MaxHP)
+		}
+		if math.Abs(stats.Bar-test.filled) > 0.05 {
+			t.Errorf("read the bar of %q as %.2f full, want %.2f", stats.Text, stats.Bar, test.filled)
+		}
+	}
+}
+
+func TestLow(t *testing.T) {
+	if !(Stats{HP: 5, MaxHP: 20, Valid: true}).Low(0.3) {
+		t.Errorf("5/20 HP isn't low")
+	}
+	if (Stats{HP: 15, MaxHP: 20, Valid: true}).Low(0.3) {
+		t.Errorf("15/20 HP is low")
+	}
+	// Only the bar is known when the numbers can't be read
+	if !(Stats{Bar: 0.2}).Low(0.3) {
+		t.Errorf("a bar 0.2 full isn't low")
+	}
+}
+
+func TestLocate(t *testing.T) {
+	if region := Locate(nil); region != params.HUDRegion {
+		t.Errorf("located the HUD at %v without any buttons, want %v", region, params.HUDRegion)
+	}
+
+	// The frame is shifted down by 20 pixels, and the HUD is still read from above the buttons
+	shift := image.Point{0, 20}
+	button := object.Object{
+		Bounds:     image.Rect(32, 432, 139, 471).Add(shift),
+		Recognized: true,
+		RecogObj:   object.RecognizedObject{Type: object.RecMap["battleOption"]},
+	}
+	region := Locate([]object.Object{button})
+	if region != params.HUDRegion.Add(shift) {
+		t.Errorf("located the HUD at %v, want %v", region, params.HUDRegion.Add(shift))
+	}
+	stats := Read(drawHUD(params.HUDRegion.Add(shift), "2", "16 / 24", 0.66), region)
+	if !stats.Valid || stats.HP != 16 || stats.MaxHP != 24 {
+		t.Errorf("read %q from the shifted HUD, want HP 16/24", stats.Text)
+	}
+}
+
+func TestYellow(t *testing.T) {
+	// The HP bar, the highlighted button and spareable names are all the same yellow,
+	// while the buttons that aren't highlighted are orange
+	if !Yellow(yellow) {
+		t.Errorf("%v isn't yellow", yellow)
+	}
+	for _, col := range []color.RGBA{{255, 127, 39, 255}, red, white} {
+		if Yellow(col) {
+			t.Errorf("%v is yellow", col)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package mask describes exactly which pixels an object covers, for collisions more precise than rectangles
+package mask
+
+import (
+	"image"
+	"sort"
+)
+
+// Mask is the set of pixels that an object covers
+type Mask struct {
+	Bounds image.Rectangle
+	Pix    []bool // Whether each pixel inside of Bounds is covered, row by row
+}
+
+// FromContour creates a mask covering the outline of a contour and everything inside of it
+func FromContour(contour []image.Point) Mask {
+	if len(contour) == 0 {
+		return Mask{}
+	}
+	bounds := image.Rectangle{contour[0], contour[0].Add(image.Point{1, 1})}
+	for _, point := range contour {
+		bounds = bounds.Union(image.Rectangle{point, point.Add(image.Point{1, 1})})
+	}
+	m := Mask{Bounds: bounds, Pix: make([]bool, bounds.Dx()*bounds.Dy())}
+
+	// Fill in every row between pairs of edge crossings
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		crossings := rowCrossings(contour, float64(y)+0.5)
+		for i := 0; i+1 < len(crossings); i += 2 {
+			for x := int(crossings[i] + 0.5); float64(x)+0.5 <= crossings[i+1]; x++ {
+				m.set(image.Point{x, y})
+			}
+		}
+	}
+
+	// The outline itself is always covered, which matters for objects only a pixel or two thick
+	for i := range contour {
+		line(&m, contour[i], contour[(i+1)%len(contour)])
+	}
+	return m
+}
+
+// FromRect creates a mask covering the whole rectangle
+func FromRect(rect image.Rectangle) Mask {
+	m := Mask{Bounds: rect, Pix: make([]bool, rect.Dx()*rect.Dy())}
+	for i := range m.Pix {
+		m.Pix[i] = true
+	}
+	return m
+}
+
+// At determines if the mask covers a point
+func (m Mask) At(point image.Point) bool {
+	if !point.In(m.Bounds) {
+		return false
+	}
+	return m.Pix[m.index(point)]
+}
+
+// Empty determines if the mask doesn't cover any pixels
+func (m Mask) Empty() bool {
+	for _, covered := range m.Pix {
+		if covered {
+			return false
+		}
+	}
+	return true
+}
+
+// Translate moves the mask by the offset given
+func (m Mask) Translate(offset image.Point) Mask {
+	return Mask{Bounds: m.Bounds.Add(offset), Pix: m.Pix}
+}
+
+// Erode shrinks the mask, only keeping the pixels that have every pixel within the distance given covered too.
+// This is used for hitboxes that are smaller than the sprite
+func (m Mask) Erode(distance int) Mask {
+	eroded := Mask{Bounds: m.Bounds, Pix: make([]bool, len(m.Pix))}
+	for y := m.Bounds.Min.Y; y < m.Bounds.Max.Y; y++ {
+		for x := m.Bounds.Min.X; x < m.Bounds.Max.X; x++ {
+			point := image.Point{x, y}
+			eroded.Pix[m.index(point)] = m.surrounded(point, distance)
+		}
+	}
+	return eroded
+}
+
+// Collides determines if two masks cover any of the same pixels
+func (m Mask) Collides(other Mask) bool {
+	overlap := m.Bounds.Intersect(other.Bounds)
+	for y := overlap.Min.Y; y < overlap.Max.Y; y++ {
+		for x := overlap.Min.X; x < overlap.Max.X; x++ {
+			point := image.Point{x, y}
+			if m.At(point) && other.At(point) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Determines if every point within the distance of a point is covered
+func (m Mask) surrounded(point image.Point, distance int) bool {
+	for dy := -distance; dy <= distance; dy++ {
+		for dx := -distance; dx <= distance; dx++ {
+			if !m.At(point.Add(image.Point{dx, dy})) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Gets the index in Pix of a point inside of the bounds
+func (m Mask) index(point image.Point) int {
+	return (point.Y-m.Bounds.Min.Y)*m.Bounds.Dx() + (point.X - m.Bounds.Min.X)
+}
+
+// Covers a point if it is inside of the bounds
+func (m *Mask) set(point image.Point) {
+	if point.In(m.Bounds) {
+		m.Pix[m.index(point)] = true
+	}
+}
+
+// Inside determines if a point is inside of the polygon that a contour outlines, not counting the outline itself
+func Inside(contour []image.Point, point image.Point) bool {
+	count := 0
+	for _, x := range rowCrossings(contour, float64(point.Y)+0.5) {
+		if x < float64(point.X)+0.5 {
+			count++
+		}
+	}
+	return count%2 == 1
+}
+
+// Gets the sorted X coordinates where the edges of a polygon cross a horizontal line
+func rowCrossings(polygon []image.Point, y float64) []float64 {
+	var crossings []float64
+	for i := range polygon {
+		a := polygon[i]
+		b := polygon[(i+1)%len(polygon)]
+		ay, by := float64(a.Y), float64(b.Y)
+		// Each edge counts as crossing if the line is between its ends, including the lower end only
+		if (ay <= y && by > y) || (by <= y && ay > y) {
+			t := (y - ay) / (by - ay)
+			crossings = append(crossings, float64(a.X)+t*float64(b.X-a.X))
+		}
+	}
+	sort.Float64s(crossings)
+	return crossings
+}
+
+// Covers the pixels on the line between two points
+func line(m *Mask, from, to image.Point) {
+	dx := abs(to.X - from.X)
+	dy := -abs(to.Y - from.Y)
+	stepX, stepY := sign(to.X-from.X), sign(to.Y-from.Y)
+	err := dx + dy
+	for point := from; ; {
+		m.set(point)
+		if point == to {
+			return
+		}
+		doubled := 2 * err
+		if doubled >= dy {
+			err += dy
+			point.X += stepX
+		}
+		if doubled <= dx {
+			err += dx
+			point.Y += stepY
+		}
+	}
+}
+
+// Gets the absolute value of a number
+func abs(num int) int {
+	if num < 0 {
+		return -num
+	}
+	return num
+}
+
+// Gets -1, 0 or 1 depending on the sign of a number
+func sign(num int) int {
+	switch {
+	case num < 0:
+		return -1
+	case num > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package mask
+
+import (
+	"image"
+	"testing"
+)
+
+// Counts the pixels that a mask covers
+func count(m Mask) int {
+	covered := 0
+	for _, pix := range m.Pix {
+		if pix {
+			covered++
+		}
+	}
+	return covered
+}
+
+// The contour OpenCV gives for a filled 10x10 square with its corner at (10, 20)
+var square = []image.Point{{10, 20}, {10, 29}, {19, 29}, {19, 20}}
+
+// A right triangle filling the lower left half of the square, including the diagonal
+var triangle = []image.Point{{10, 20}, {10, 29}, {19, 29}}
+
+// TestFromContour checks that a contour's mask covers its outline and everything inside of it, and nothing else
+func TestFromContour(t *testing.T) {
+	m := FromContour(square)
+	if m.Bounds != image.Rect(10, 20, 20, 30) {
+		t.Errorf("the square's mask has the bounds %v, want %v", m.Bounds, image.Rect(10, 20, 20, 30))
+	}
+	if count(m) != 100 {
+		t.Errorf("the square's mask covers %v pixels, want 100", count(m))
+	}
+
+	m = FromContour(triangle)
+	cases := []struct {
+		point image.Point
+		want  bool
+	}{
+		{image.Point{10, 20}, true},  // The top corner
+		{image.Point{19, 29}, true},  // The right corner
+		{image.Point{14, 24}, true},  // On the diagonal
+		{image.Point{12, 27}, true},  // Inside
+		{image.Point{17, 22}, false}, // In the other half of the square
+		{image.Point{9, 25}, false},  // Outside of the bounds
+	}
+	for _, c := range cases {
+		if m.At(c.point) != c.want {
+			t.Errorf("the triangle's mask at %v is %v, want %v", c.point, m.At(c.point), c.want)
+		}
+	}
+
+	// Lines only a pixel thick are still covered
+	m = FromContour([]image.Point{{0, 0}, {5, 0}})
+	if count(m) != 6 {
+		t.Errorf("a 6 pixel line's mask covers %v pixels", count(m))
+	}
+
+	if !FromContour(nil).Empty() {
+		t.Error("an empty contour's mask isn't empty")
+	}
+}
+
+// TestErode checks that eroding removes the pixels near the edges
+func TestErode(t *testing.T) {
+	m := FromContour(square).Erode(2)
+	if count(m) != 36 {
+		t.Errorf("eroding the 10x10 square by 2 left %v pixels, want 36", count(m))
+	}
+	if m.At(image.Point{11, 21}) || !m.At(image.Point{12, 22}) || !m.At(image.Point{17, 27}) || m.At(image.Point{18, 27}) {
+		t.Error("eroding the square by 2 didn't keep exactly the 6x6 pixels in the middle")
+	}
+	if !FromContour(triangle).Erode(5).Empty() {
+		t.Error("eroding the triangle by more than half of its size left pixels")
+	}
+}
+
+// TestCollides checks that masks only collide when they share a pixel, not just when their bounds overlap
+func TestCollides(t *testing.T) {
+	lower := FromContour(triangle)
+	upper := FromContour([]image.Point{{12, 20}, {19, 20}, {19, 27}})
+	if lower.Collides(upper) {
+		t.Error("the triangles in opposite halves of the square collide")
+	}
+	if !lower.Collides(upper.Translate(image.Point{-2, 0})) {
+		t.Error("the upper triangle moved onto the diagonal doesn't collide with the lower one")
+	}
+	if lower.Collides(FromRect(image.Rect(100, 100, 110, 110))) {
+		t.Error("masks far apart collide")
+	}
+	if !FromRect(image.Rect(0, 0, 5, 5)).Collides(FromRect(image.Rect(4, 4, 10, 10))) {
+		t.Error("rectangles sharing a corner pixel don't collide")
+	}
+}
+
+// TestInside checks which points are inside of a contour
+func TestInside(t *testing.T) {
+	if !Inside(square, image.Point{15, 25}) {
+		t.Error("the middle of the square isn't inside of it")
+	}
+	if Inside(square, image.Point{25, 25}) || Inside(square, image.Point{15, 35}) {
+		t.Error("points outside of the square are inside of it")
+	}
+	if Inside(triangle, image.Point{17, 22}) || !Inside(triangle, image.Point{12, 27}) {
+		t.Error("the triangle's halves are the wrong way around")
+	}
+}
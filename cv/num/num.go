@@ -14,3 +14,8 @@ func within(num1 int, num2 int, rng int) bool {
 func PntWithin(pnt1 image.Point, pnt2 image.Point, rng int) bool {
 	return ((within(pnt1.X, pnt2.X, rng)) && (within(pnt1.Y, pnt2.Y, rng)))
 }
+
+// Center finds the point in the middle of a rectangle
+func Center(rect image.Rectangle) image.Point {
+	return image.Point{(rect.Min.X + rect.Max.X) / 2, (rect.Min.Y + rect.Max.Y) / 2}
+}
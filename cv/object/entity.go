@@ -0,0 +1,258 @@
+package object
+
+import (
+	"image"
+	"sort"
+
+	"gitlab.com/256/Underbot/cv/num"
+)
+
+// Facing is the direction that an entity is looking
+type Facing int
+
+// The directions an entity can be facing. Side doesn't say whether it is left or right
+const (
+	FacingUnknown Facing = iota
+	FacingFront
+	FacingBack
+	FacingSide
+)
+
+// String gets the name of the direction
+func (f Facing) String() string {
+	switch f {
+	case FacingFront:
+		return "front"
+	case FacingBack:
+		return "back"
+	case FacingSide:
+		return "side"
+	}
+	return "unknown"
+}
+
+// Part is one of the recognizable pieces that a composite entity can be made of
+type Part struct {
+	Type   RecognizableObject
+	Facing Facing // The direction the entity is facing if this part is seen, or FacingUnknown if it doesn't tell
+}
+
+// Above is a rule that an upper part must be above a lower part of the same entity, such as a face above a body.
+// Parts that would break it are split into separate entities
+type Above struct {
+	Upper RecognizableObject
+	Lower RecognizableObject
+}
+
+// Composite describes an entity that the CV recognizes as several separate parts, such as Frisk's face and body
+type Composite struct {
+	Name   string
+	Parts  []Part
+	Rules  []Above
+	Within int // How many pixels apart the parts can be and still belong to the same entity
+}
+
+// Entity is a group of recognized parts that make up a single thing on screen
+type Entity struct {
+	Name       string
+	Bounds     image.Rectangle // Surrounds all of the parts
+	Facing     Facing
+	Confidence float64 // From 0 to 1, based on the scores of the parts
+	Walking    bool    // Whether any of the parts matched a frame of its animation other than the standing one. See animations()
+	Parts      []Object
+}
+
+// Composites holds the entities that are grouped from their parts every frame
+var Composites = []Composite{
+	{
+		Name: "frisk",
+		Parts: []Part{
+			{RecMap["friskFrontFace"], FacingFront},
+			{RecMap["friskSideFace"], FacingSide},
+			{RecMap["friskBody"], FacingUnknown}, // The same from the front and the back
+			{RecMap["friskSideBody"], FacingSide},
+			{RecMap["friskUpperBody"], FacingFront},
+			{RecMap["friskBack"], FacingBack},
+		},
+		Rules: []Above{
+			{RecMap["friskFrontFace"], RecMap["friskBody"]},
+			{RecMap["friskSideFace"], RecMap["friskSideBody"]},
+		},
+		Within: 10,
+	},
+	{
+		Name: "toriel",
+		Parts: []Part{
+			{RecMap["torielFront"], FacingFront},
+			{RecMap["torielSide"], FacingSide},
+		},
+		Within: 40,
+	},
+}
+
+// Group combines the recognized objects that are parts of each composite into entities
+func Group(recognized []Object, composites []Composite) []Entity {
+	var entities []Entity
+	for _, comp := range composites {
+		var parts []Object
+		for _, obj := range recognized {
+			if _, ok := comp.part(obj); ok {
+				parts = append(parts, obj)
+			}
+		}
+		for _, cluster := range comp.clusters(parts) {
+			entities = append(entities, comp.build(cluster))
+		}
+	}
+	return entities
+}
+
+// FindEntity returns the most confident entity with the name given
+func FindEntity(name string, entities []Entity) (Entity, bool) {
+	var best Entity
+	found := false
+	for _, entity := range entities {
+		if entity.Name == name && (!found || entity.Confidence > best.Confidence) {
+			best = entity
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Gets the part of the composite that an object was recognized as
+func (comp Composite) part(obj Object) (Part, bool) {
+	if !obj.Recognized {
+		return Part{}, false
+	}
+	for _, part := range comp.Parts {
+		if part.Type.Is(obj.RecogObj.Type) {
+			return part, true
+		}
+	}
+	return Part{}, false
+}
+
+// Makes an entity out of a cluster of parts
+func (comp Composite) build(cluster []Object) Entity {
+	entity := Entity{Name: comp.Name, Parts: cluster}
+
+	var scoreSum float64
+	var facingScore float64
+	for i, obj := range cluster {
+		if i == 0 {
+			entity.Bounds = obj.Bounds
+		} else {
+			entity.Bounds = entity.Bounds.Union(obj.Bounds)
+		}
+		scoreSum += obj.RecogObj.Score
+		if obj.RecogObj.Type.Frame > 0 {
+			entity.Walking = true
+		}
+
+		// The best scoring part that says which way the entity faces decides it
+		part, _ := comp.part(obj)
+		if part.Facing != FacingUnknown && obj.RecogObj.Score > facingScore {
+			entity.Facing = part.Facing
+			facingScore = obj.RecogObj.Score
+		}
+	}
+	entity.Confidence = scoreSum / float64(len(cluster))
+	return entity
+}
+
+// Splits parts into groups where each part is within the distance of another in its group, closest parts first.
+// Two groups aren't joined if that would break one of the rules, so parts of entities next to each other are kept apart
+func (comp Composite) clusters(objs []Object) [][]Object {
+	type pair struct {
+		i, j int
+		gap  int
+	}
+	var pairs []pair
+	for i := range objs {
+		for j := i + 1; j < len(objs); j++ {
+			if g := gap(objs[i].Bounds, objs[j].Bounds); g <= comp.Within {
+				pairs = append(pairs, pair{i, j, g})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return pairs[a].gap < pairs[b].gap
+	})
+
+	group := make([]int, len(objs))
+	for i := range group {
+		group[i] = i
+	}
+	for _, p := range pairs {
+		if group[p.i] != group[p.j] && comp.compatible(objs, group, group[p.i], group[p.j]) {
+			relabel(group, group[p.j], group[p.i])
+		}
+	}
+
+	var found [][]Object
+	index := make(map[int]int)
+	for i, obj := range objs {
+		if _, ok := index[group[i]]; !ok {
+			index[group[i]] = len(found)
+			found = append(found, nil)
+		}
+		found[index[group[i]]] = append(found[index[group[i]]], obj)
+	}
+	return found
+}
+
+// Determines if the parts in two groups can be joined without breaking any of the rules
+func (comp Composite) compatible(objs []Object, group []int, a, b int) bool {
+	for i := range objs {
+		if group[i] != a {
+			continue
+		}
+		for j := range objs {
+			if group[j] == b && (comp.breaks(objs[i], objs[j]) || comp.breaks(objs[j], objs[i])) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Determines if two parts break a rule, which is when a part that has to be above the other isn't
+func (comp Composite) breaks(upper, lower Object) bool {
+	for _, rule := range comp.Rules {
+		if upper.RecogObj.Type.Is(rule.Upper) && lower.RecogObj.Type.Is(rule.Lower) &&
+			num.Center(upper.Bounds).Y >= num.Center(lower.Bounds).Y {
+			return true
+		}
+	}
+	return false
+}
+
+// Changes every label of from into to
+func relabel(group []int, from, to int) {
+	for i := range group {
+		if group[i] == from {
+			group[i] = to
+		}
+	}
+}
+
+// Gets the number of pixels between the edges of two rectangles, which is 0 if they touch or overlap
+func gap(a, b image.Rectangle) int {
+	dx := 0
+	if b.Min.X > a.Max.X {
+		dx = b.Min.X - a.Max.X
+	} else if a.Min.X > b.Max.X {
+		dx = a.Min.X - b.Max.X
+	}
+	dy := 0
+	if b.Min.Y > a.Max.Y {
+		dy = b.Min.Y - a.Max.Y
+	} else if a.Min.Y > b.Max.Y {
+		dy = a.Min.Y - b.Max.Y
+	}
+	if dx > dy {
+		return dx
+	}
+	return dy
+}
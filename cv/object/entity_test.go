@@ -0,0 +1,52 @@
+package object
+
+import (
+	"image"
+	"testing"
+)
+
+// Makes an object recognized as the type with the name given
+func part(name string, bounds image.Rectangle) Object {
+	return Object{Bounds: bounds, Recognized: true, RecogObj: RecognizedObject{Type: RecMap[name], Score: 1}}
+}
+
+// TestGroupSplits checks that parts close enough to be grouped are still split when a face would be below a body
+func TestGroupSplits(t *testing.T) {
+	cases := []struct {
+		name  string
+		parts []Object
+		want  int
+	}{
+		{"face above body", []Object{
+			part("friskFrontFace", image.Rect(100, 100, 127, 121)),
+			part("friskBody", image.Rect(102, 123, 125, 140)),
+		}, 1},
+		{"face below body", []Object{
+			part("friskBody", image.Rect(102, 100, 125, 117)),
+			part("friskFrontFace", image.Rect(100, 119, 127, 140)),
+		}, 2},
+		// A second Frisk's face next to the first Frisk's body belongs with its own body, not the first face's
+		{"two frisks side by side", []Object{
+			part("friskFrontFace", image.Rect(100, 100, 127, 121)),
+			part("friskBody", image.Rect(102, 123, 125, 140)),
+			part("friskFrontFace", image.Rect(130, 130, 157, 151)),
+			part("friskBody", image.Rect(132, 153, 155, 170)),
+		}, 2},
+	}
+	for _, c := range cases {
+		entities := Group(c.parts, Composites)
+		if len(entities) != c.want {
+			t.Errorf("%s: got %v entities, want %v", c.name, len(entities), c.want)
+		}
+		for _, entity := range entities {
+			for _, upper := range entity.Parts {
+				for _, lower := range entity.Parts {
+					if Composites[0].breaks(upper, lower) {
+						t.Errorf("%s: an entity has a %s that isn't above its %s", c.name,
+							upper.RecogObj.Type.Name, lower.RecogObj.Type.Name)
+					}
+				}
+			}
+		}
+	}
+}
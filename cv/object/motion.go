@@ -0,0 +1,48 @@
+package object
+
+import (
+	"image"
+	"time"
+)
+
+// Motion describes how an object has been moving across frames, as found by the tracker
+type Motion struct {
+	TrackID  int      // Stays the same for the same object across frames. 0 if the object isn't tracked
+	History  []Sample // Where the object was in past frames, oldest first. The last sample is the current frame
+	Velocity Velocity // How fast the object is moving
+}
+
+// Sample is where a tracked object was at a point in time
+type Sample struct {
+	Bounds image.Rectangle
+	At     time.Time
+}
+
+// Velocity is a speed in pixels per second in each direction
+type Velocity struct {
+	X float64
+	Y float64
+}
+
+// Tracked determines if the tracker has followed the object for more than one frame
+func (m Motion) Tracked() bool {
+	return len(m.History) > 1
+}
+
+// Last gets the most recent sample of the object. The zero Sample is returned if there is no history
+func (m Motion) Last() Sample {
+	if len(m.History) == 0 {
+		return Sample{}
+	}
+	return m.History[len(m.History)-1]
+}
+
+// Predict estimates where the object will be after the amount of time given, assuming it keeps its velocity
+func (m Motion) Predict(ahead time.Duration) image.Rectangle {
+	last := m.Last().Bounds
+	shift := image.Point{
+		int(m.Velocity.X * ahead.Seconds()),
+		int(m.Velocity.Y * ahead.Seconds()),
+	}
+	return last.Add(shift)
+}
@@ -5,15 +5,46 @@ import (
 	"image/color"
 
 	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/mask"
 )
 
 // Object holds information for a detected object found in the window
 type Object struct {
 	Bounds     image.Rectangle // Rectangle describing the object's dimensions
-	ID         int             // Used for debugging to provide a method of describing an object
+	ID         int             // Stays the same for the same object across frames once it is tracked. Used for debugging
 	Color      color.Color     // The color of the pixel in the center of the object
+	EdgeColor  color.Color     // The average color of the object's outline
 	Recognized bool
 	RecogObj   RecognizedObject // Holds information for the object it is recognized as if it is recognized
+	Parent     *Object          // The object whose contour this object's contour is directly inside of. Nil if it isn't inside anything
+	Children   []*Object        // The objects directly inside of this object
+	Motion     Motion           // How the object has been moving across frames
+	Candidates []Candidate      // What the object could be recognized as, best match first
+	Contour    []image.Point    // The outline the object was detected from, in the frame's coordinates
+	Hash       uint64           // The perceptual hash of what the object looks like. Only set for objects that could be enemy sprites
+}
+
+// Candidate is something that an object could be recognized as, along with how well it matches
+type Candidate struct {
+	Type  RecognizableObject
+	Score float64 // From 0 (not at all) to 1 (exactly)
+}
+
+// Ambiguous determines if the object's best candidates are within the margin of each other,
+// meaning the recognizers overlap and the best one might not be right
+func (obj *Object) Ambiguous(margin float64) bool {
+	if len(obj.Candidates) < 2 {
+		return false
+	}
+	return obj.Candidates[0].Score-obj.Candidates[1].Score <= margin
+}
+
+// Mask gets the pixels that the object covers, falling back to its bounds if it has no contour
+func (obj *Object) Mask() mask.Mask {
+	if len(obj.Contour) == 0 {
+		return mask.FromRect(obj.Bounds)
+	}
+	return mask.FromContour(obj.Contour)
 }
 
 // NewObject creates new instance of an Object with parameter checking
@@ -57,6 +88,7 @@ func (obj *Object) Check() error {
 type RecognizedObject struct {
 	Parent *Object
 	Type   RecognizableObject
+	Score  float64 // How well the object matched the type, from 0 to 1
 }
 
 // NewRecognizedObject creates a new instance of RecognizedObject safely
@@ -90,6 +122,19 @@ type RecognizableObject struct {
 	Size     image.Point
 	Color    color.Color
 	Leniance int // How far off the object can be in terms of size. If set to -1, will be default set in params package
+	Frame    int // Which frame of its animation this is. 0 for objects that aren't animated
+	Frames   int // How many frames are in the animation. 0 for objects that aren't animated
+}
+
+// Is determines if two recognizable objects are the same thing.
+// The frames of an animation share a name, so any frame is the same as any other
+func (recogObj RecognizableObject) Is(other RecognizableObject) bool {
+	return recogObj.Name == other.Name
+}
+
+// Animated determines if the recognizable object is a frame of an animation
+func (recogObj RecognizableObject) Animated() bool {
+	return recogObj.Frames > 1
 }
 
 // Create new RecognizedObject with parameter checking
@@ -141,8 +186,19 @@ var Dialogue = []RecognizableObject{
 	RecMap["dialogueBox"],
 }
 
-// RecognizableObjects holds all the possible recognizable objects in the game
-var RecognizableObjects = []RecognizableObject{
+// Enemies is a list holding the enemies that are recognized by their size and color instead of by their sprite's hash
+var Enemies = []RecognizableObject{
+	RecMap["dummy"],
+}
+
+// TextBoxes is a list holding the boxes that text is read from
+var TextBoxes = []RecognizableObject{
+	RecMap["dialogueBox"],
+	RecMap["narratorBox"],
+}
+
+// RecognizableObjects holds all the possible recognizable objects in the game, including every frame of the animations
+var RecognizableObjects = numberFrames(append([]RecognizableObject{
 	// 0: The largest rectangle in battleMenu that usually holds narration, item options, etc.
 	newSpecs("narratorBox", 574, 139, color.RGBA{0, 0, 0, 255}, -1),
 	// 1: Traditional heart. No gravity and moves around the fightBox
@@ -189,16 +245,46 @@ var RecognizableObjects = []RecognizableObject{
 	newSpecs("entrance", 65, 105, color.RGBA{255, 255, 255, 255}, -1),
 	// 21: Battle Options
 	newSpecs("battleOption", 107, 39, color.RGBA{0, 0, 0, 255}, -1),
+}, animations()...))
+
+// The extra frames of animated objects. Each has the same name as the object above that it is a frame of,
+// and the one above is the first frame, which is shown while standing still.
+// Only sizes measured from captures of the game belong here, such as by clicking on the object in the debugging window
+// mid-animation, along with a test that recognizes a frame from a capture.
+// None have been measured yet, so animated sprites aren't recognized: nothing has more than one frame,
+// and Frisk is only recognized while standing still, which keeps Entity.Walking false
+func animations() []RecognizableObject {
+	return []RecognizableObject{}
+}
+
+// Sets the Frame and Frames of every recognizable object that shares its name with others, in the order they appear
+func numberFrames(recObjects []RecognizableObject) []RecognizableObject {
+	counts := make(map[string]int)
+	for _, obj := range recObjects {
+		counts[obj.Name]++
+	}
+	seen := make(map[string]int)
+	for i, obj := range recObjects {
+		if counts[obj.Name] > 1 {
+			recObjects[i].Frame = seen[obj.Name]
+			recObjects[i].Frames = counts[obj.Name]
+		}
+		seen[obj.Name]++
+	}
+	return recObjects
 }
 
 // RecMap is map of the above slice indexed by the name attribute
 var RecMap = Map(RecognizableObjects)
 
-// Map turns a slice of RecognizableObject into a map that can be searched with the string name
+// Map turns a slice of RecognizableObject into a map that can be searched with the string name.
+// Animations are mapped to their first frame
 func Map(recObjects []RecognizableObject) (recMap map[string]RecognizableObject) {
 	recMap = make(map[string]RecognizableObject)
 	for _, obj := range recObjects {
-		recMap[obj.Name] = obj
+		if _, ok := recMap[obj.Name]; !ok {
+			recMap[obj.Name] = obj
+		}
 	}
 	return recMap
 }
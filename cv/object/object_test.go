@@ -0,0 +1,29 @@
+package object
+
+import (
+	"image/color"
+	"testing"
+)
+
+// TestNumberFrames checks that objects sharing a name are numbered as frames of one animation, in order
+func TestNumberFrames(t *testing.T) {
+	numbered := numberFrames([]RecognizableObject{
+		newSpecs("walker", 10, 20, color.RGBA{255, 0, 0, 255}, -1),
+		newSpecs("still", 5, 5, color.RGBA{0, 255, 0, 255}, -1),
+		newSpecs("walker", 12, 20, color.RGBA{255, 0, 0, 255}, -1),
+		newSpecs("walker", 14, 20, color.RGBA{255, 0, 0, 255}, -1),
+	})
+	want := []struct{ frame, frames int }{{0, 3}, {0, 0}, {1, 3}, {2, 3}}
+	for i, obj := range numbered {
+		if obj.Frame != want[i].frame || obj.Frames != want[i].frames {
+			t.Errorf("%s %v: got frame %v of %v, want %v of %v", obj.Name, i, obj.Frame, obj.Frames,
+				want[i].frame, want[i].frames)
+		}
+	}
+
+	// Looking up an animation by name gives its first frame
+	if first := Map(numbered)["walker"]; first.Size.X != 10 || first.Frame != 0 {
+		t.Errorf("looking up the animation gave frame %v with a width of %v, want frame 0 with a width of 10",
+			first.Frame, first.Size.X)
+	}
+}
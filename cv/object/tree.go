@@ -0,0 +1,85 @@
+package object
+
+import "image"
+
+// Link fills in the Parent and Children fields of every object from the contour tree.
+// parents holds the index in objs of the object that each one is directly inside of, or -1 if it isn't inside of any.
+// The links point into objs, so it shouldn't be appended to afterwards
+func Link(objs []Object, parents []int) {
+	for i := range objs {
+		objs[i].Parent = nil
+		objs[i].Children = nil
+	}
+	for i, parent := range parents {
+		if parent < 0 {
+			continue
+		}
+		objs[i].Parent = &objs[parent]
+		objs[parent].Children = append(objs[parent].Children, &objs[i])
+	}
+}
+
+// Descendants returns every object inside of obj, no matter how deeply nested
+func (obj *Object) Descendants() []*Object {
+	var found []*Object
+	for _, child := range obj.Children {
+		found = append(found, child)
+		found = append(found, child.Descendants()...)
+	}
+	return found
+}
+
+// Inside returns the objects inside of obj that have been recognized as one of the wanted objects
+func (obj *Object) Inside(wanted []RecognizableObject) []*Object {
+	var found []*Object
+	for _, desc := range obj.Descendants() {
+		if !desc.Recognized {
+			continue
+		}
+		for _, want := range wanted {
+			if desc.RecogObj.Type.Is(want) {
+				found = append(found, desc)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// Ancestors returns the objects that obj is inside of, starting with the outermost
+func (obj *Object) Ancestors() []*Object {
+	var found []*Object
+	for parent := obj.Parent; parent != nil; parent = parent.Parent {
+		found = append([]*Object{parent}, found...)
+	}
+	return found
+}
+
+// At returns the chain of objects containing the point, starting with the outermost
+func At(point image.Point, objs []Object) []*Object {
+	var chain []*Object
+	var level []*Object
+	for i := range objs {
+		if objs[i].Parent == nil {
+			level = append(level, &objs[i])
+		}
+	}
+	for {
+		next := childAt(point, level)
+		if next == nil {
+			return chain
+		}
+		chain = append(chain, next)
+		level = next.Children
+	}
+}
+
+// Finds the first object in a level of the tree that contains the point
+func childAt(point image.Point, level []*Object) *Object {
+	for _, obj := range level {
+		if point.In(obj.Bounds) {
+			return obj
+		}
+	}
+	return nil
+}
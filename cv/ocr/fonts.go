@@ -0,0 +1,356 @@
+package ocr
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// The glyphs below were drawn by hand to the proportions of the game's fonts rather than cut out of screenshots,
+// so some characters can be a pixel off. Glyphs learned from screenshots with Learn are loaded over them at startup.
+// No screenshots have been checked in yet, so nothing tests them against the game's text: the other tests draw text
+// with these same glyphs. Crops of the dialogueBox, the narratorBox and the HUD row go in testdata/screenshots.json,
+// which TestScreenshots reads and -learn seeds the fonts from
+
+// HUD is the font of the numbers and labels in the HUD row during a battle.
+// Letters of the player's name aren't included, so they are read as Unknown
+var HUD = Font{
+	Name:    "hud",
+	Scale:   2,
+	Space:   3,
+	LineGap: 3,
+	Glyphs: []Glyph{
+		{'0', []string{
+			".###.",
+			"#...#",
+			"#..##",
+			"#.#.#",
+			"##..#",
+			"#...#",
+			".###.",
+		}},
+		{'1', []string{
+			"..#..",
+			".##..",
+			"..#..",
+			"..#..",
+			"..#..",
+			"..#..",
+			".###.",
+		}},
+		{'2', []string{
+			".###.",
+			"#...#",
+			"....#",
+			"...#.",
+			"..#..",
+			".#...",
+			"#####",
+		}},
+		{'3', []string{
+			"####.",
+			"....#",
+			"....#",
+			".###.",
+			"....#",
+			"....#",
+			"####.",
+		}},
+		{'4', []string{
+			"...#.",
+			"..##.",
+			".#.#.",
+			"#..#.",
+			"#####",
+			"...#.",
+			"...#.",
+		}},
+		{'5', []string{
+			"#####",
+			"#....",
+			"####.",
+			"....#",
+			"....#",
+			"#...#",
+			".###.",
+		}},
+		{'6', []string{
+			".###.",
+			"#....",
+			"#....",
+			"####.",
+			"#...#",
+			"#...#",
+			".###.",
+		}},
+		{'7', []string{
+			"#####",
+			"....#",
+			"...#.",
+			"..#..",
+			".#...",
+			".#...",
+			".#...",
+		}},
+		{'8', []string{
+			".###.",
+			"#...#",
+			"#...#",
+			".###.",
+			"#...#",
+			"#...#",
+			".###.",
+		}},
+		{'9', []string{
+			".###.",
+			"#...#",
+			"#...#",
+			".####",
+			"....#",
+			"....#",
+			".###.",
+		}},
+		{'/', []string{
+			"....#",
+			"...#.",
+			"...#.",
+			"..#..",
+			".#...",
+			".#...",
+			"#....",
+		}},
+		{'L', []string{
+			"#....",
+			"#....",
+			"#....",
+			"#....",
+			"#....",
+			"#....",
+			"#####",
+		}},
+		{'V', []string{
+			"#...#",
+			"#...#",
+			"#...#",
+			"#...#",
+			".#.#.",
+			".#.#.",
+			"..#..",
+		}},
+		{'H', []string{
+			"#..#",
+			"#..#",
+			"####",
+			"#..#",
+			"#..#",
+		}},
+		{'P', []string{
+			"###.",
+			"#..#",
+			"###.",
+			"#...",
+			"#...",
+		}},
+	},
+}
+
+// Dialogue is the font that most of the game's text is written in, such as the narrator's and most characters' lines
+var Dialogue = Font{
+	Name:     "dialogue",
+	Scale:    2,
+	Space:    3,
+	LineGap:  3,
+	Baseline: 7,
+	Glyphs: []Glyph{
+		{'A', []string{".###.", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"}},
+		{'B', []string{"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."}},
+		{'C', []string{".###.", "#...#", "#....", "#....", "#....", "#...#", ".###."}},
+		{'D', []string{"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."}},
+		{'E', []string{"#####", "#....", "#....", "####.", "#....", "#....", "#####"}},
+		{'F', []string{"#####", "#....", "#....", "####.", "#....", "#....", "#...."}},
+		{'G', []string{".###.", "#...#", "#....", "#.###", "#...#", "#...#", ".####"}},
+		{'H', []string{"#...#", "#...#", "#...#", "#####", "#...#", "#...#", "#...#"}},
+		{'I', []string{"###", ".#.", ".#.", ".#.", ".#.", ".#.", "###"}},
+		{'J', []string{"..###", "...#.", "...#.", "...#.", "...#.", "#..#.", ".##.."}},
+		{'K', []string{"#...#", "#..#.", "#.#..", "##...", "#.#..", "#..#.", "#...#"}},
+		{'L', []string{"#....", "#....", "#....", "#....", "#....", "#....", "#####"}},
+		{'M', []string{"#...#", "##.##", "#.#.#", "#.#.#", "#...#", "#...#", "#...#"}},
+		{'N', []string{"#...#", "#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#"}},
+		{'O', []string{".###.", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."}},
+		{'P', []string{"####.", "#...#", "#...#", "####.", "#....", "#....", "#...."}},
+		{'Q', []string{".###.", "#...#", "#...#", "#...#", "#.#.#", "#..#.", ".##.#"}},
+		{'R', []string{"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"}},
+		{'S', []string{".####", "#....", "#....", ".###.", "....#", "....#", "####."}},
+		{'T', []string{"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."}},
+		{'U', []string{"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."}},
+		{'V', []string{"#...#", "#...#", "#...#", "#...#", "#...#", ".#.#.", "..#.."}},
+		{'W', []string{"#...#", "#...#", "#...#", "#.#.#", "#.#.#", "#.#.#", ".#.#."}},
+		{'X', []string{"#...#", "#...#", ".#.#.", "..#..", ".#.#.", "#...#", "#...#"}},
+		{'Y', []string{"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."}},
+		{'Z', []string{"#####", "....#", "...#.", "..#..", ".#...", "#....", "#####"}},
+		{'a', []string{".....", ".....", ".###.", "....#", ".####", "#...#", ".####"}},
+		{'b', []string{"#....", "#....", "#.##.", "##..#", "#...#", "#...#", "####."}},
+		{'c', []string{".....", ".....", ".###.", "#....", "#....", "#...#", ".###."}},
+		{'d', []string{"....#", "....#", ".##.#", "#..##", "#...#", "#...#", ".####"}},
+		{'e', []string{".....", ".....", ".###.", "#...#", "#####", "#....", ".###."}},
+		{'f', []string{"..##.", ".#..#", ".#...", "###..", ".#...", ".#...", ".#..."}},
+		{'g', []string{".....", ".....", ".####", "#...#", "#...#", ".####", "....#", "....#", ".###."}},
+		{'h', []string{"#....", "#....", "#.##.", "##..#", "#...#", "#...#", "#...#"}},
+		{'i', []string{".#.", "...", "##.", ".#.", ".#.", ".#.", "###"}},
+		{'j', []string{"...#", "....", "..##", "...#", "...#", "...#", "...#", "#..#", ".##."}},
+		{'k', []string{"#....", "#....", "#..#.", "#.#..", "##...", "#.#..", "#..#."}},
+		{'l', []string{"##.", ".#.", ".#.", ".#.", ".#.", ".#.", "###"}},
+		{'m', []string{".....", ".....", "##.#.", "#.#.#", "#.#.#", "#...#", "#...#"}},
+		{'n', []string{".....", ".....", "#.##.", "##..#", "#...#", "#...#", "#...#"}},
+		{'o', []string{".....", ".....", ".###.", "#...#", "#...#", "#...#", ".###."}},
+		{'p', []string{".....", ".....", "####.", "#...#", "#...#", "####.", "#....", "#....", "#...."}},
+		{'q', []string{".....", ".....", ".####", "#...#", "#...#", ".####", "....#", "....#", "....#"}},
+		{'r', []string{".....", ".....", "#.##.", "##..#", "#....", "#....", "#...."}},
+		{'s', []string{".....", ".....", ".###.", "#....", ".###.", "....#", "####."}},
+		{'t', []string{".#...", ".#...", "###..", ".#...", ".#...", ".#..#", "..##."}},
+		{'u', []string{".....", ".....", "#...#", "#...#", "#...#", "#..##", ".##.#"}},
+		{'v', []string{".....", ".....", "#...#", "#...#", "#...#", ".#.#.", "..#.."}},
+		{'w', []string{".....", ".....", "#...#", "#...#", "#.#.#", "#.#.#", ".#.#."}},
+		{'x', []string{".....", ".....", "#...#", ".#.#.", "..#..", ".#.#.", "#...#"}},
+		{'y', []string{".....", ".....", "#...#", "#...#", "#...#", ".####", "....#", "....#", ".###."}},
+		{'z', []string{".....", ".....", "#####", "...#.", "..#..", ".#...", "#####"}},
+		{'0', []string{".###.", "#...#", "#..##", "#.#.#", "##..#", "#...#", ".###."}},
+		{'1', []string{"..#..", ".##..", "..#..", "..#..", "..#..", "..#..", ".###."}},
+		{'2', []string{".###.", "#...#", "....#", "...#.", "..#..", ".#...", "#####"}},
+		{'3', []string{"####.", "....#", "....#", ".###.", "....#", "....#", "####."}},
+		{'4', []string{"...#.", "..##.", ".#.#.", "#..#.", "#####", "...#.", "...#."}},
+		{'5', []string{"#####", "#....", "####.", "....#", "....#", "#...#", ".###."}},
+		{'6', []string{".###.", "#....", "#....", "####.", "#...#", "#...#", ".###."}},
+		{'7', []string{"#####", "....#", "...#.", "..#..", ".#...", ".#...", ".#..."}},
+		{'8', []string{".###.", "#...#", "#...#", ".###.", "#...#", "#...#", ".###."}},
+		{'9', []string{".###.", "#...#", "#...#", ".####", "....#", "....#", ".###."}},
+		{'.', []string{".", ".", ".", ".", ".", ".", "#"}},
+		{',', []string{".", ".", ".", ".", ".", ".", "#", "#"}},
+		{'\'', []string{"#", "#"}},
+		{'!', []string{"#", "#", "#", "#", "#", ".", "#"}},
+		{'?', []string{".###.", "#...#", "....#", "...#.", "..#..", ".....", "..#.."}},
+		{'-', []string{"...", "...", "...", "###"}},
+		{':', []string{".", ".", "#", ".", ".", ".", "#"}},
+		{';', []string{".", ".", "#", ".", ".", ".", "#", "#"}},
+		{'*', []string{".....", "#.#.#", ".###.", "#####", ".###.", "#.#.#"}},
+		{'(', []string{"..#", ".#.", "#..", "#..", "#..", ".#.", "..#"}},
+		{')', []string{"#..", ".#.", "..#", "..#", "..#", ".#.", "#.."}},
+	},
+}
+
+// Sans is the font that Sans talks in, which only has lowercase letters
+var Sans = Font{
+	Name:     "sans",
+	Scale:    2,
+	Space:    3,
+	LineGap:  3,
+	Baseline: 6,
+	Glyphs: []Glyph{
+		{'a', []string{"....", "....", ".###", "#..#", "#..#", ".###"}},
+		{'b', []string{"#...", "#...", "###.", "#..#", "#..#", "###."}},
+		{'c', []string{"....", "....", ".###", "#...", "#...", ".###"}},
+		{'d', []string{"...#", "...#", ".###", "#..#", "#..#", ".###"}},
+		{'e', []string{"....", "....", ".##.", "####", "#...", ".###"}},
+		{'f', []string{"..##", ".#..", "###.", ".#..", ".#..", ".#.."}},
+		{'g', []string{"....", "....", ".###", "#..#", "#..#", ".###", "...#", "###."}},
+		{'h', []string{"#...", "#...", "###.", "#..#", "#..#", "#..#"}},
+		{'i', []string{"#", ".", "#", "#", "#", "#"}},
+		{'j', []string{"..#", "...", "..#", "..#", "..#", "..#", "..#", "##."}},
+		{'k', []string{"#...", "#...", "#..#", "#.#.", "##..", "#.##"}},
+		{'l', []string{"#", "#", "#", "#", "#", "#"}},
+		{'m', []string{".....", ".....", "##.#.", "#.#.#", "#.#.#", "#.#.#"}},
+		{'n', []string{"....", "....", "###.", "#..#", "#..#", "#..#"}},
+		{'o', []string{"....", "....", ".##.", "#..#", "#..#", ".##."}},
+		{'p', []string{"....", "....", "###.", "#..#", "#..#", "###.", "#...", "#..."}},
+		{'q', []string{"....", "....", ".###", "#..#", "#..#", ".###", "...#", "...#"}},
+		{'r', []string{"....", "....", "#.##", "##..", "#...", "#..."}},
+		{'s', []string{"....", "....", ".###", ".#..", "..#.", "###."}},
+		{'t', []string{".#..", ".#..", "###.", ".#..", ".#..", "..##"}},
+		{'u', []string{"....", "....", "#..#", "#..#", "#..#", ".###"}},
+		{'v', []string{"....", "....", "#..#", "#..#", ".##.", ".##."}},
+		{'w', []string{".....", ".....", "#...#", "#.#.#", "#.#.#", ".#.#."}},
+		{'x', []string{"....", "....", "#..#", ".##.", ".##.", "#..#"}},
+		{'y', []string{"....", "....", "#..#", "#..#", "#..#", ".###", "...#", "###."}},
+		{'z', []string{"....", "....", "####", "..#.", ".#..", "####"}},
+		{'.', []string{".", ".", ".", ".", ".", "#"}},
+		{',', []string{".", ".", ".", ".", ".", "#", "#"}},
+		{'\'', []string{"#", "#"}},
+		{'!', []string{"#", "#", "#", "#", ".", "#"}},
+		{'?', []string{".##.", "#..#", "..#.", ".#..", "....", ".#.."}},
+		{'*', []string{"....", "#..#", ".##.", ".##.", "#..#"}},
+	},
+}
+
+// Papyrus is the font that Papyrus talks in, which only has tall uppercase letters
+var Papyrus = Font{
+	Name:     "papyrus",
+	Scale:    2,
+	Space:    3,
+	LineGap:  3,
+	Baseline: 8,
+	Glyphs: []Glyph{
+		{'A', []string{".##.", "#..#", "#..#", "#..#", "####", "#..#", "#..#", "#..#"}},
+		{'B', []string{"###.", "#..#", "#..#", "###.", "#..#", "#..#", "#..#", "###."}},
+		{'C', []string{".###", "#...", "#...", "#...", "#...", "#...", "#...", ".###"}},
+		{'D', []string{"###.", "#..#", "#..#", "#..#", "#..#", "#..#", "#..#", "###."}},
+		{'E', []string{"####", "#...", "#...", "###.", "#...", "#...", "#...", "####"}},
+		{'F', []string{"####", "#...", "#...", "###.", "#...", "#...", "#...", "#..."}},
+		{'G', []string{".###", "#...", "#...", "#.##", "#..#", "#..#", "#..#", ".###"}},
+		{'H', []string{"#..#", "#..#", "#..#", "####", "#..#", "#..#", "#..#", "#..#"}},
+		{'I', []string{"###", ".#.", ".#.", ".#.", ".#.", ".#.", ".#.", "###"}},
+		{'J', []string{"..##", "...#", "...#", "...#", "...#", "...#", "#..#", ".##."}},
+		{'K', []string{"#..#", "#..#", "#.#.", "##..", "##..", "#.#.", "#..#", "#..#"}},
+		{'L', []string{"#...", "#...", "#...", "#...", "#...", "#...", "#...", "####"}},
+		{'M', []string{"#..#", "####", "####", "#..#", "#..#", "#..#", "#..#", "#..#"}},
+		{'N', []string{"#..#", "##.#", "##.#", "#.##", "#.##", "#..#", "#..#", "#..#"}},
+		{'O', []string{".##.", "#..#", "#..#", "#..#", "#..#", "#..#", "#..#", ".##."}},
+		{'P', []string{"###.", "#..#", "#..#", "###.", "#...", "#...", "#...", "#..."}},
+		{'Q', []string{".##.", "#..#", "#..#", "#..#", "#..#", "#.##", "#..#", ".#.#"}},
+		{'R', []string{"###.", "#..#", "#..#", "###.", "#.#.", "#..#", "#..#", "#..#"}},
+		{'S', []string{".###", "#...", "#...", ".##.", "...#", "...#", "...#", "###."}},
+		{'T', []string{"###", ".#.", ".#.", ".#.", ".#.", ".#.", ".#.", ".#."}},
+		{'U', []string{"#..#", "#..#", "#..#", "#..#", "#..#", "#..#", "#..#", ".##."}},
+		{'V', []string{"#..#", "#..#", "#..#", "#..#", "#..#", "#..#", ".##.", ".##."}},
+		{'W', []string{"#..#", "#..#", "#..#", "#..#", "#..#", "####", "####", "#..#"}},
+		{'X', []string{"#..#", "#..#", ".##.", ".##.", ".##.", ".##.", "#..#", "#..#"}},
+		{'Y', []string{"#.#", "#.#", "#.#", ".#.", ".#.", ".#.", ".#.", ".#."}},
+		{'Z', []string{"####", "...#", "..#.", "..#.", ".#..", ".#..", "#...", "####"}},
+		{'.', []string{".", ".", ".", ".", ".", ".", ".", "#"}},
+		{',', []string{".", ".", ".", ".", ".", ".", ".", "#", "#"}},
+		{'\'', []string{"#", "#"}},
+		{'!', []string{"#", "#", "#", "#", "#", "#", ".", "#"}},
+		{'?', []string{".##.", "#..#", "...#", "..#.", ".#..", ".#..", "....", ".#.."}},
+		{'*', []string{"....", "#..#", ".##.", ".##.", "#..#"}},
+	},
+}
+
+// Fonts holds the fonts that the text in dialogue and menus can be written in, with the most common one first
+var Fonts = []Font{Dialogue, Sans, Papyrus}
+
+// Named gets the font with the name given
+func Named(name string) (Font, bool) {
+	for _, font := range append([]Font{HUD}, Fonts...) {
+		if font.Name == name {
+			return font, true
+		}
+	}
+	return Font{}, false
+}
+
+// Use replaces the font with the same name, such as with one that has glyphs learned from screenshots
+func Use(font Font) error {
+	switch font.Name {
+	case HUD.Name:
+		HUD = font
+	case Dialogue.Name:
+		Dialogue = font
+	case Sans.Name:
+		Sans = font
+	case Papyrus.Name:
+		Papyrus = font
+	default:
+		return errors.New(fmt.Sprintf("there is no font named %s", font.Name))
+	}
+	Fonts = []Font{Dialogue, Sans, Papyrus}
+	return nil
+}
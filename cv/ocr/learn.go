@@ -0,0 +1,133 @@
+package ocr
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Learn cuts the glyphs of a font out of a screenshot of text that is already known, such as dialogue that was
+// written down while playing. The lines of text have to be separated by newlines, and spaces are skipped.
+// If the font is aligned to a baseline, each glyph is padded to sit the same way above it that it did on the line
+func Learn(img *image.RGBA, region image.Rectangle, font Font, text string, lit Lit) ([]Glyph, error) {
+	region = region.Intersect(img.Bounds())
+	bits := newBitmap(img, region, lit)
+	rows := runs(bits.rowCounts(), font.LineGap*font.Scale)
+	lines := strings.Split(text, "\n")
+	if len(rows) != len(lines) {
+		return nil, errors.New(fmt.Sprintf("found %v lines of text, but %v were given", len(rows), len(lines)))
+	}
+
+	var glyphs []Glyph
+	for i, line := range lines {
+		chars := []rune(strings.Replace(line, " ", "", -1))
+		cols := runs(bits.columnCounts(rows[i]), 1)
+		if len(cols) != len(chars) {
+			return nil, errors.New(fmt.Sprintf("found %v glyphs on line %v, but %q has %v characters",
+				len(cols), i+1, line, len(chars)))
+		}
+		pieces := make([]piece, len(cols))
+		bottoms := make([]int, len(cols))
+		for j := range cols {
+			pieces[j] = newPiece(bits.crop(cols[j], rows[i]))
+			bottoms[j] = pieces[j].bottom
+		}
+		baseline := mostCommon(bottoms)
+		for j, char := range chars {
+			glyph := Glyph{Char: char, Rows: shrink(pieces[j].pixels, font.Scale)}
+			// Glyphs are lined up by how far above the baseline they end, the same way they are read
+			if font.Baseline > 0 {
+				rise := roundDiv(baseline-pieces[j].bottom, font.Scale)
+				for pad := font.Baseline - rise - len(glyph.Rows); pad > 0; pad-- {
+					glyph.Rows = append([]string{strings.Repeat(".", len(glyph.Rows[0]))}, glyph.Rows...)
+				}
+			}
+			glyphs = append(glyphs, glyph)
+		}
+	}
+	return glyphs, nil
+}
+
+// Merge gets a copy of the font with the glyphs given in place of the ones it has for the same characters
+func (font Font) Merge(glyphs []Glyph) Font {
+	merged := font
+	merged.Glyphs = append([]Glyph{}, font.Glyphs...)
+	for _, glyph := range glyphs {
+		replaced := false
+		for i := range merged.Glyphs {
+			if merged.Glyphs[i].Char == glyph.Char {
+				merged.Glyphs[i] = glyph
+				replaced = true
+			}
+		}
+		if !replaced {
+			merged.Glyphs = append(merged.Glyphs, glyph)
+		}
+	}
+	return merged
+}
+
+// The way a glyph is saved, with the character as a string so that the file can be read and edited by hand
+type savedGlyph struct {
+	Char string   `json:"char"`
+	Rows []string `json:"rows"`
+}
+
+// Save writes the glyphs of a font so that they can be loaded in place of the ones built in
+func Save(out io.Writer, glyphs []Glyph) error {
+	saved := make([]savedGlyph, len(glyphs))
+	for i, glyph := range glyphs {
+		saved[i] = savedGlyph{string(glyph.Char), glyph.Rows}
+	}
+	encoded, err := json.MarshalIndent(saved, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode the glyphs")
+	}
+	_, err = out.Write(encoded)
+	if err != nil {
+		return errors.Wrap(err, "failed to write the glyphs")
+	}
+	return nil
+}
+
+// Load reads glyphs written by Save
+func Load(in io.Reader) ([]Glyph, error) {
+	var saved []savedGlyph
+	err := json.NewDecoder(in).Decode(&saved)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode the glyphs")
+	}
+	glyphs := make([]Glyph, len(saved))
+	for i, glyph := range saved {
+		chars := []rune(glyph.Char)
+		if len(chars) != 1 {
+			return nil, errors.New(fmt.Sprintf("glyph %v is for %q, which isn't a single character", i, glyph.Char))
+		}
+		glyphs[i] = Glyph{chars[0], glyph.Rows}
+	}
+	return glyphs, nil
+}
+
+// Turns a bitmap of text drawn at a scale back into the rows of a glyph,
+// using the pixel in the middle of each block of screen pixels that a glyph pixel was drawn as
+func shrink(bits bitmap, scale int) []string {
+	rows := make([]string, roundDiv(bits.height, scale))
+	width := roundDiv(bits.width, scale)
+	for y := range rows {
+		var row strings.Builder
+		for x := 0; x < width; x++ {
+			px, py := x*scale+scale/2, y*scale+scale/2
+			if px < bits.width && py < bits.height && bits.at(px, py) {
+				row.WriteByte('#')
+			} else {
+				row.WriteByte('.')
+			}
+		}
+		rows[y] = row.String()
+	}
+	return rows
+}
@@ -0,0 +1,526 @@
+// Package ocr reads the text drawn in Undertale's bitmap fonts by matching it against glyph templates
+package ocr
+
+import (
+	"image"
+	"image/color"
+	"strings"
+
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+// Unknown is the character read for anything that doesn't match any glyph of the font well enough.
+// It isn't in any of the fonts, so it can't be confused with a character that was read
+const Unknown = '~'
+
+// How much is taken off of a glyph's score for each pixel that its size or position is off by
+const mismatchPenalty = 0.05
+
+// Glyph is what a single character looks like in a font.
+// Each row is a string where '#' is a lit pixel and anything else is unlit
+type Glyph struct {
+	Char rune
+	Rows []string
+}
+
+// Font is a set of glyphs drawn the same way
+type Font struct {
+	Name    string
+	Glyphs  []Glyph
+	Scale   int // How many screen pixels each pixel of a glyph takes up
+	Space   int // How many unlit glyph pixels between two glyphs are read as a space
+	LineGap int // How many unlit glyph pixels between two rows of text separate them into different lines
+	// How many rows of each glyph are above the line that the text sits on, so that glyphs that only differ
+	// in height (such as commas and apostrophes) can be told apart. If 0, the glyphs aren't aligned and this isn't checked
+	Baseline int
+}
+
+// Text is what was read from one of the boxes that text is shown in
+type Text struct {
+	Box    string          // What the box was recognized as
+	Bounds image.Rectangle // Where the text was read from
+	Font   string          // The name of the font that matched the text best
+	Lines  []string
+	Words  []Word
+}
+
+// Word is a run of characters without any spaces, along with where it was read from
+type Word struct {
+	Text   string
+	Line   int // The index of the line the word is on
+	Bounds image.Rectangle
+	Color  color.RGBA // The average color of the word's lit pixels, which tells white text from yellow text
+}
+
+// String joins the lines of the text with spaces
+func (t Text) String() string {
+	return strings.Join(t.Lines, " ")
+}
+
+// Lit decides whether a pixel is part of the text
+type Lit func(col color.RGBA) bool
+
+// Bright treats every pixel whose channels are all at least the level given as text, which picks out white text
+func Bright(level uint8) Lit {
+	return func(col color.RGBA) bool {
+		return col.R >= level && col.G >= level && col.B >= level
+	}
+}
+
+// Yellow treats every pixel whose red and green are at least the level given and whose blue isn't as text,
+// which picks out the yellow names of enemies that can be spared
+func Yellow(level uint8) Lit {
+	return func(col color.RGBA) bool {
+		return col.R >= level && col.G >= level && col.B < level
+	}
+}
+
+// Either treats every pixel that any of the lits treats as text as text
+func Either(lits ...Lit) Lit {
+	return func(col color.RGBA) bool {
+		for _, lit := range lits {
+			if lit(col) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Read reads the text inside of a region of the image, returning each line of it
+func Read(img *image.RGBA, region image.Rectangle, font Font, lit Lit) []string {
+	region = region.Intersect(img.Bounds())
+	lines, _, _ := readBits(newBitmap(img, region, lit), region.Min, font)
+	return lines
+}
+
+// ReadBest reads the text inside of a region of the image with whichever of the fonts recognizes the most of it,
+// returning the font used along with the lines and the words on them. The first font wins any ties
+func ReadBest(img *image.RGBA, region image.Rectangle, fonts []Font, lit Lit) (Font, []string, []Word) {
+	region = region.Intersect(img.Bounds())
+	bits := newBitmap(img, region, lit)
+	var best Font
+	var bestLines []string
+	var bestWords []Word
+	bestUnknown := -1.0
+	for _, font := range fonts {
+		lines, words, unknown := readBits(bits, region.Min, font)
+		if bestUnknown < 0 || unknown < bestUnknown {
+			best, bestLines, bestWords, bestUnknown = font, lines, words, unknown
+		}
+		// A later font can only win by recognizing more, so there is no need to try them once everything is recognized
+		if bestUnknown == 0 {
+			break
+		}
+	}
+	for i := range bestWords {
+		bestWords[i].Color = averageColor(img, bestWords[i].Bounds, lit)
+	}
+	return best, bestLines, bestWords
+}
+
+// Gets the average color of the lit pixels inside of bounds
+func averageColor(img *image.RGBA, bounds image.Rectangle, lit Lit) color.RGBA {
+	var r, g, b, count int
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			col := img.RGBAAt(x, y)
+			if lit(col) {
+				r, g, b = r+int(col.R), g+int(col.G), b+int(col.B)
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return color.RGBA{}
+	}
+	return color.RGBA{uint8(r / count), uint8(g / count), uint8(b / count), 255}
+}
+
+// Reads the lines of text in a bitmap whose top left corner is at origin in the image,
+// along with the words on them and the fraction of the characters that were Unknown
+func readBits(bits bitmap, origin image.Point, font Font) ([]string, []Word, float64) {
+	var lines []string
+	var words []Word
+	chars, unknown := 0, 0
+	templates := font.templates()
+	for _, rows := range runs(bits.rowCounts(), font.LineGap*font.Scale) {
+		lineWords := readLine(bits, rows, font, templates)
+		if len(lineWords) == 0 {
+			continue
+		}
+		texts := make([]string, len(lineWords))
+		for i, word := range lineWords {
+			texts[i] = word.Text
+			word.Line = len(lines)
+			word.Bounds = word.Bounds.Add(origin)
+			words = append(words, word)
+			chars += len([]rune(word.Text))
+			unknown += strings.Count(word.Text, string(Unknown))
+		}
+		lines = append(lines, strings.Join(texts, " "))
+	}
+	if chars == 0 {
+		return lines, words, 0
+	}
+	return lines, words, float64(unknown) / float64(chars)
+}
+
+// ReadString reads all of the text inside of a region of the image, joining the lines with spaces
+func ReadString(img *image.RGBA, region image.Rectangle, font Font, lit Lit) string {
+	return strings.Join(Read(img, region, font, lit), " ")
+}
+
+// Reads the words on a single line of text, which covers the range of rows given, using the font's templates.
+// The bounds of the words are in the bitmap's coordinates
+func readLine(bits bitmap, rows span, font Font, templates []template) []Word {
+	glyphs := runs(bits.columnCounts(rows), 1)
+	pieces := make([]piece, len(glyphs))
+	bottoms := make([]int, len(glyphs))
+	for i, cols := range glyphs {
+		pieces[i] = newPiece(bits.crop(cols, rows))
+		bottoms[i] = pieces[i].bottom
+	}
+	baseline := mostCommon(bottoms)
+
+	var words []Word
+	var text strings.Builder
+	for i, cols := range glyphs {
+		if i == 0 || cols.start-glyphs[i-1].end >= font.Space*font.Scale {
+			if i > 0 {
+				words[len(words)-1].Text = text.String()
+				text.Reset()
+			}
+			words = append(words, Word{Bounds: image.Rect(cols.start, rows.start, cols.end, rows.end)})
+		}
+		words[len(words)-1].Bounds.Max.X = cols.end
+
+		// How far above the baseline the piece ends, in glyph pixels
+		rise := roundDiv(baseline-pieces[i].bottom, font.Scale)
+		text.WriteRune(font.match(templates, pieces[i].pixels, rise))
+	}
+	if len(words) > 0 {
+		words[len(words)-1].Text = text.String()
+	}
+	return words
+}
+
+// A single glyph's worth of lit pixels cut out of a line
+type piece struct {
+	pixels bitmap // Trimmed to the lit pixels
+	bottom int    // The row of the line below the lowest lit pixel
+}
+
+// Trims the lit pixels of a glyph cut out of a line, remembering where its bottom was in the line
+func newPiece(cut bitmap) piece {
+	rows := runs(cut.rowCounts(), cut.height)
+	if len(rows) == 0 {
+		return piece{}
+	}
+	return piece{pixels: cut.trim(), bottom: rows[0].end}
+}
+
+// A glyph prepared for matching, which is made once for each read instead of once for every character on screen
+type template struct {
+	char   rune
+	pixels bitmap // Trimmed to the lit pixels
+	rise   int    // How far above the baseline the glyph ends, or 0 if the font isn't aligned to one
+}
+
+// Prepares the glyphs of the font for matching
+func (font Font) templates() []template {
+	templates := make([]template, len(font.Glyphs))
+	for i, glyph := range font.Glyphs {
+		templates[i] = template{char: glyph.Char, pixels: glyph.bitmap().trim()}
+		if font.Baseline > 0 {
+			templates[i].rise = glyph.rise(font.Baseline)
+		}
+	}
+	return templates
+}
+
+// Finds the glyph that best matches the lit pixels given, or Unknown if none of them match well enough
+func (font Font) match(templates []template, pixels bitmap, rise int) rune {
+	best := Unknown
+	bestScore := params.GlyphMinScore
+	for _, glyph := range templates {
+		score := glyph.score(pixels, font.Scale)
+		if font.Baseline > 0 {
+			off := abs(glyph.rise - rise)
+			if off > 1 {
+				continue
+			}
+			score -= mismatchPenalty * float64(off)
+		}
+		if score >= bestScore {
+			best = glyph.char
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// Gets how far above the baseline the glyph ends, which is negative for glyphs that hang below it
+func (glyph Glyph) rise(baseline int) int {
+	rows := runs(glyph.bitmap().rowCounts(), len(glyph.Rows))
+	if len(rows) == 0 {
+		return 0
+	}
+	return baseline - rows[0].end
+}
+
+// Scores how well the glyph matches the trimmed lit pixels, from 0 to 1.
+// The glyph is trimmed too, so they only have to line up with each other and not with the line
+func (glyph template) score(pixels bitmap, scale int) float64 {
+	template := glyph.pixels
+	if template.width == 0 || pixels.width == 0 {
+		return 0
+	}
+	// Glyphs more than a pixel off in size can't be the same character
+	if abs(pixels.width-template.width*scale) > scale || abs(pixels.height-template.height*scale) > scale {
+		return 0
+	}
+
+	// Being a pixel off is allowed for rounding, but a glyph of the exact size is a better match
+	off := float64(abs(pixels.width-template.width*scale)+abs(pixels.height-template.height*scale)) / float64(scale)
+
+	// Compare each pixel of the glyph with the screen pixel in the middle of where it would be drawn
+	agree := 0
+	for y := 0; y < template.height; y++ {
+		for x := 0; x < template.width; x++ {
+			px := (2*x + 1) * pixels.width / (2 * template.width)
+			py := (2*y + 1) * pixels.height / (2 * template.height)
+			if template.at(x, y) == pixels.at(px, py) {
+				agree++
+			}
+		}
+	}
+	return float64(agree)/float64(template.width*template.height) - mismatchPenalty*off
+}
+
+// Turns the glyph's rows into a bitmap
+func (glyph Glyph) bitmap() bitmap {
+	width := 0
+	for _, row := range glyph.Rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	bits := bitmap{width: width, height: len(glyph.Rows), pix: make([]bool, width*len(glyph.Rows))}
+	for y, row := range glyph.Rows {
+		for x, char := range row {
+			bits.pix[y*width+x] = char == '#'
+		}
+	}
+	return bits
+}
+
+// A grid of pixels that are either part of the text or not
+type bitmap struct {
+	width  int
+	height int
+	pix    []bool
+}
+
+// A range of rows or columns, including start and excluding end
+type span struct {
+	start int
+	end   int
+}
+
+// Makes a bitmap of which pixels in a region of the image are lit
+func newBitmap(img *image.RGBA, region image.Rectangle, lit Lit) bitmap {
+	bits := bitmap{width: region.Dx(), height: region.Dy(), pix: make([]bool, region.Dx()*region.Dy())}
+	for y := 0; y < bits.height; y++ {
+		for x := 0; x < bits.width; x++ {
+			bits.pix[y*bits.width+x] = lit(img.RGBAAt(region.Min.X+x, region.Min.Y+y))
+		}
+	}
+	return bits
+}
+
+// Determines if the pixel at a point is lit
+func (bits bitmap) at(x, y int) bool {
+	return bits.pix[y*bits.width+x]
+}
+
+// Counts the lit pixels in each row
+func (bits bitmap) rowCounts() []int {
+	counts := make([]int, bits.height)
+	for y := 0; y < bits.height; y++ {
+		for x := 0; x < bits.width; x++ {
+			if bits.at(x, y) {
+				counts[y]++
+			}
+		}
+	}
+	return counts
+}
+
+// Counts the lit pixels in each column, only looking at the rows given
+func (bits bitmap) columnCounts(rows span) []int {
+	counts := make([]int, bits.width)
+	for y := rows.start; y < rows.end; y++ {
+		for x := 0; x < bits.width; x++ {
+			if bits.at(x, y) {
+				counts[x]++
+			}
+		}
+	}
+	return counts
+}
+
+// Copies part of the bitmap
+func (bits bitmap) crop(cols, rows span) bitmap {
+	cropped := bitmap{width: cols.end - cols.start, height: rows.end - rows.start}
+	cropped.pix = make([]bool, cropped.width*cropped.height)
+	for y := 0; y < cropped.height; y++ {
+		for x := 0; x < cropped.width; x++ {
+			cropped.pix[y*cropped.width+x] = bits.at(cols.start+x, rows.start+y)
+		}
+	}
+	return cropped
+}
+
+// Removes the unlit rows and columns around the edges of the bitmap
+func (bits bitmap) trim() bitmap {
+	rows := runs(bits.rowCounts(), bits.height)
+	cols := runs(bits.columnCounts(span{0, bits.height}), bits.width)
+	if len(rows) == 0 || len(cols) == 0 {
+		return bitmap{}
+	}
+	return bits.crop(cols[0], rows[0])
+}
+
+// Finds the runs of non-zero counts, joining runs that are less than gap apart
+func runs(counts []int, gap int) []span {
+	var found []span
+	for i, count := range counts {
+		if count == 0 {
+			continue
+		}
+		if len(found) > 0 && i-found[len(found)-1].end < gap {
+			found[len(found)-1].end = i + 1
+			continue
+		}
+		found = append(found, span{i, i + 1})
+	}
+	return found
+}
+
+// Finds the number that appears the most, picking the largest if there is a tie
+func mostCommon(nums []int) int {
+	counts := make(map[int]int)
+	best := 0
+	for _, num := range nums {
+		counts[num]++
+		if counts[num] > counts[best] || (counts[num] == counts[best] && num > best) {
+			best = num
+		}
+	}
+	return best
+}
+
+// Misreads counts how many characters would have to be changed, added or removed to turn the text read into the text wanted.
+// Characters that couldn't be read match anything
+func Misreads(read, wanted string) int {
+	a, b := []rune(read), []rune(wanted)
+	last := make([]int, len(b)+1)
+	for j := range last {
+		last[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			change := 1
+			if a[i-1] == b[j-1] || a[i-1] == Unknown {
+				change = 0
+			}
+			current[j] = smallest(last[j-1]+change, last[j]+1, current[j-1]+1)
+		}
+		last = current
+	}
+	return last[len(b)]
+}
+
+// Gets the smallest of the numbers
+func smallest(nums ...int) int {
+	least := nums[0]
+	for _, num := range nums[1:] {
+		if num < least {
+			least = num
+		}
+	}
+	return least
+}
+
+// Divides two numbers, rounding to the nearest whole number
+func roundDiv(num, div int) int {
+	if num < 0 {
+		return -roundDiv(-num, div)
+	}
+	return (num + div/2) / div
+}
+
+// Gets the absolute value of a number
+func abs(num int) int {
+	if num < 0 {
+		return -num
+	}
+	return num
+}
+
+// Draw draws text onto the image in the font, with its top left corner at the point given, the way the game would.
+// Characters that the font doesn't have are drawn as spaces, and newlines start a new line below.
+// This is how the fonts are checked to read back what was drawn with them
+func Draw(img *image.RGBA, at image.Point, font Font, text string, col color.RGBA) {
+	height := 0
+	for _, glyph := range font.Glyphs {
+		if len(glyph.Rows) > height {
+			height = len(glyph.Rows)
+		}
+	}
+	x, y := at.X, at.Y
+	for _, char := range text {
+		if char == '\n' {
+			x, y = at.X, y+(height+font.LineGap)*font.Scale
+			continue
+		}
+		glyph, ok := font.glyph(char)
+		if !ok {
+			x += font.Space * font.Scale
+			continue
+		}
+		// Glyphs are drawn a pixel apart no matter how much unlit space is around them in the template
+		bits := glyph.bitmap()
+		cols := runs(bits.columnCounts(span{0, bits.height}), bits.width)
+		if len(cols) == 0 {
+			continue
+		}
+		for gy := 0; gy < bits.height; gy++ {
+			for gx := cols[0].start; gx < cols[0].end; gx++ {
+				if !bits.at(gx, gy) {
+					continue
+				}
+				left, top := x+(gx-cols[0].start)*font.Scale, y+gy*font.Scale
+				for py := top; py < top+font.Scale; py++ {
+					for px := left; px < left+font.Scale; px++ {
+						img.SetRGBA(px, py, col)
+					}
+				}
+			}
+		}
+		x += (cols[0].end - cols[0].start + 1) * font.Scale
+	}
+}
+
+// Gets the glyph of a character
+func (font Font) glyph(char rune) (Glyph, bool) {
+	for _, glyph := range font.Glyphs {
+		if glyph.Char == char {
+			return glyph, true
+		}
+	}
+	return Glyph{}, false
+}
@@ -0,0 +1,138 @@
+package ocr
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+var white = color.RGBA{255, 255, 255, 255}
+
+// Where text is drawn in the tests, which is inside of where the dialogueBox would be
+var textBox = image.Rect(40, 320, 600, 460)
+
+// Draws text onto a black frame the size of the game's window. Text drawn this way uses the same glyphs that read it,
+// so the tests using it only check that drawing, reading and learning agree with each other. See TestScreenshots
+// for reading the game's text
+func drawText(font Font, text string) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 640, 480))
+	for i := range img.Pix {
+		if i%4 == 3 {
+			img.Pix[i] = 255
+		}
+	}
+	Draw(img, textBox.Min.Add(image.Point{8, 8}), font, text, white)
+	return img
+}
+
+// Splits every character of the font into words of a few characters each, so that they fit on a line
+func allCharacters(font Font) []string {
+	var words []string
+	var word strings.Builder
+	for i, glyph := range font.Glyphs {
+		word.WriteRune(glyph.Char)
+		if i%6 == 5 || i == len(font.Glyphs)-1 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	return words
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, font := range append([]Font{HUD}, Fonts...) {
+		for _, word := range allCharacters(font) {
+			lines := Read(drawText(font, word), textBox, font, Bright(200))
+			if len(lines) != 1 || lines[0] != word {
+				t.Errorf("read %q drawn in the %s font as %q", word, font.Name, lines)
+			}
+		}
+	}
+}
+
+func TestRoundTripLines(t *testing.T) {
+	tests := []struct {
+		font Font
+		text string
+	}{
+		{Dialogue, "* Hello, my child.\n* Welcome to the RUINS!"},
+		{HUD, "LV 1 HP 20 / 20"},
+		{Sans, "* heya.\n* you've been busy, huh?"},
+		{Papyrus, "NYEH HEH HEH!"},
+	}
+	for _, test := range tests {
+		want := strings.Split(test.text, "\n")
+		lines := Read(drawText(test.font, test.text), textBox, test.font, Bright(200))
+		if strings.Join(lines, "\n") != test.text {
+			t.Errorf("read %q drawn in the %s font as %q", want, test.font.Name, lines)
+		}
+	}
+}
+
+func TestReadBest(t *testing.T) {
+	for _, font := range Fonts {
+		text := strings.Join(allCharacters(font), " ")
+		best, lines, _ := ReadBest(drawText(font, text), textBox, Fonts, Bright(200))
+		if best.Name != font.Name {
+			t.Errorf("read %q drawn in the %s font with the %s font as %q", text, font.Name, best.Name, lines)
+		}
+	}
+}
+
+func TestLearn(t *testing.T) {
+	// Glyphs cut out of text drawn in a font read the text back the same as the font does
+	text := "* Hello, my child.\n* Welcome to the RUINS!"
+	img := drawText(Dialogue, text)
+	glyphs, err := Learn(img, textBox, Dialogue, text, Bright(200))
+	if err != nil {
+		t.Fatal(err)
+	}
+	learned := Font{Name: "learned", Scale: Dialogue.Scale, Space: Dialogue.Space, LineGap: Dialogue.LineGap,
+		Baseline: Dialogue.Baseline}.Merge(glyphs)
+	lines := Read(img, textBox, learned, Bright(200))
+	if strings.Join(lines, "\n") != text {
+		t.Errorf("read %q with the learned glyphs as %q", text, lines)
+	}
+
+	// The text given has to match what is on screen
+	_, err = Learn(img, textBox, Dialogue, "* Hello", Bright(200))
+	if err == nil {
+		t.Errorf("learned from one line of text when there are two")
+	}
+	_, err = Learn(img, textBox, Dialogue, "* Hello, my child\n* Welcome to the RUINS!", Bright(200))
+	if err == nil {
+		t.Errorf("learned from a line missing a character")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	var saved bytes.Buffer
+	err := Save(&saved, Dialogue.Glyphs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	glyphs, err := Load(&saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(glyphs) != len(Dialogue.Glyphs) {
+		t.Fatalf("loaded %v glyphs, want %v", len(glyphs), len(Dialogue.Glyphs))
+	}
+	for i, glyph := range glyphs {
+		if glyph.Char != Dialogue.Glyphs[i].Char || strings.Join(glyph.Rows, "") != strings.Join(Dialogue.Glyphs[i].Rows, "") {
+			t.Errorf("loaded %q as %q", Dialogue.Glyphs[i].Char, glyph.Char)
+		}
+	}
+}
+
+// Reading a full dialogueBox happens for every text box in every frame, which has to fit in the 33ms of a frame
+func BenchmarkReadBest(b *testing.B) {
+	img := drawText(Dialogue, "* Hello, my child.\n* Welcome to your new home,\n* innocent one.")
+	lit := Either(Bright(200), Yellow(200))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ReadBest(img, textBox, Fonts, lit)
+	}
+}
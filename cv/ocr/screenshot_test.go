@@ -0,0 +1,73 @@
+package ocr
+
+import (
+	"encoding/json"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A crop of a screenshot of the game with the text it shows, in the same format as the samples that -learn takes
+type screenshot struct {
+	Image  string `json:"image"` // Relative to the testdata directory
+	Font   string `json:"font"`
+	Region [4]int `json:"region"`
+	Text   string `json:"text"`
+}
+
+// Loads the screenshots listed in testdata/screenshots.json, skipping the test if none have been checked in
+func screenshots(t *testing.T) []screenshot {
+	file, err := os.Open(filepath.Join("testdata", "screenshots.json"))
+	if os.IsNotExist(err) {
+		t.Skip("no screenshots of the game have been checked in to testdata yet")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var shots []screenshot
+	err = json.NewDecoder(file).Decode(&shots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return shots
+}
+
+// Loads a screenshot as an RGBA image
+func loadPNG(t *testing.T, name string) *image.RGBA {
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	decoded, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(decoded.Bounds())
+	draw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	return img
+}
+
+// TestScreenshots reads text from crops of the game itself, which the other tests can't stand in for
+// as they draw the text with the same glyphs that read it. The fonts are seeded from these crops
+// by passing testdata/screenshots.json to -learn
+func TestScreenshots(t *testing.T) {
+	for _, shot := range screenshots(t) {
+		font, ok := Named(shot.Font)
+		if !ok {
+			t.Errorf("%s: there is no font named %s", shot.Image, shot.Font)
+			continue
+		}
+		img := loadPNG(t, shot.Image)
+		region := image.Rect(shot.Region[0], shot.Region[1], shot.Region[2], shot.Region[3])
+		lines := Read(img, region, font, Bright(200))
+		if strings.Join(lines, "\n") != shot.Text {
+			t.Errorf("%s: read %q in the %s font, want %q", shot.Image, lines, font.Name, shot.Text)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package params
 
-import "image/color"
+import (
+	"image"
+	"image/color"
+	"time"
+)
 
 const (
 	// Coloring determines how objects will be colored:
@@ -15,6 +19,16 @@ const (
 // For example an object 15x15 would be recognized for a RecognizedObject calling for 13x13 with a leniance setting of 2
 var Leniance = 3
 
+// MinScore is the lowest score from 0 to 1 that the best candidate of an object needs to be recognized
+var MinScore = 0.0
+
+// SizeOnlyWeight is multiplied with the score of recognizers that only check size (black ones),
+// so that recognizers that also check color win when both match
+var SizeOnlyWeight = 0.8
+
+// AmbiguityMargin is how close the scores of the two best candidates of an object must be for it to be reported as ambiguous
+var AmbiguityMargin = 0.1
+
 // FailedLimit is how many approximate frames must go by without GetWanted working before warning the user
 // and using the unstuck algorithm
 var FailedLimit = 100
@@ -29,3 +43,136 @@ var TileColor = color.RGBA{0, 0, 0, 255}
 
 // PathColor is the color of the tiles that Frisk will walk on
 var PathColor = color.RGBA{0, 0, 255, 255}
+
+// FullFrameInterval is how many frames can go by while the AI is focused on regions of interest
+// before the whole frame is processed again to notice changes elsewhere
+var FullFrameInterval = 30
+
+// FocusColor is the color that the regions of interest are outlined with
+var FocusColor = color.RGBA{255, 128, 0, 255}
+
+// DiffStep is how many pixels apart the samples outside of the focused regions are when checking if a frame is a duplicate
+// of the last one. Smaller steps notice smaller changes, but take longer
+var DiffStep = 4
+
+// TickDuplicates determines if the AI should still be ran on frames that are duplicates of the last one.
+// If false, the AI only acts on real game frames
+var TickDuplicates = false
+
+// TrackHistory is how many past positions are kept for each tracked object
+var TrackHistory = 10
+
+// TrackMaxMissed is how many frames a tracked object can go unseen before its track is forgotten
+var TrackMaxMissed = 5
+
+// TrackMaxJump is how many pixels an object that doesn't overlap its predicted position can be away from it
+// and still be considered the same object
+var TrackMaxJump = 40.0
+
+// TrackColorWeight is how much differing colors lower the score of an object being the same as a tracked one, from 0 to 1.
+// Some of the score is kept for colors that don't match, as sprites flash and change color while animating
+var TrackColorWeight = 0.6
+
+// EntityColor is the color that the bounds of entities grouped from several parts are drawn with
+var EntityColor = color.RGBA{255, 255, 0, 255}
+
+// GameFrame is how long the game shows each of its frames for, as Undertale runs at 30 frames a second
+var GameFrame = time.Second / 30
+
+// PredictFrames is how many game frames ahead the positions of projectiles are predicted
+var PredictFrames = 5
+
+// FightBoxBorder is how many pixels in from each side of the fightBox the inside edge of its border can be,
+// which leaves room to spare around the border's width
+var FightBoxBorder = 10
+
+// ProjectileColor is the color that projectiles and their predicted positions are outlined with
+var ProjectileColor = color.RGBA{255, 0, 255, 255}
+
+// HeartHitboxInset is how many pixels the heart's hitbox is shrunk by from its sprite on every side,
+// as attacks can graze the edges of the heart without hurting it
+var HeartHitboxInset = 2
+
+// HitCooldown is how long the heart can't be hurt again for after being hit, so a single hit isn't counted twice
+var HitCooldown = time.Second
+
+// FlashMaxGap is how long the heart can vanish for and still count as blinking. After a hit, the heart blinks on and off
+// for as long as it can't be hurt, vanishing for a couple of game frames at a time
+var FlashMaxGap = 150 * time.Millisecond
+
+// FlashBlinks is how many blinks in a row it takes for the heart to count as flashing after a hit
+var FlashBlinks = 2
+
+// HUDRegion is where the HUD row with the player's name, LV and HP is shown during a battle when the frame isn't shifted.
+// Its height and sides are always used, but it is moved up or down to sit above the battle buttons when they are found
+var HUDRegion = image.Rect(30, 398, 610, 424)
+
+// HUDAboveButtons is how many pixels above the top of the battle buttons the bottom of the HUD row is
+var HUDAboveButtons = 8
+
+// TextBrightness is how bright every channel of a pixel has to be for it to be read as part of white text
+var TextBrightness uint8 = 200
+
+// GlyphMinScore is how well text has to match a glyph for it to be read as that character, from 0 to 1
+var GlyphMinScore = 0.8
+
+// LowHP is the fraction of the max HP at or below which the HP counts as low, and it is time to heal or flee
+var LowHP = 0.3
+
+// TextInset is how many pixels inside of a text box's bounds the text is read from, which skips its border
+var TextInset = 8
+
+// TypingSettle is how long the text in the dialogue box has to stay the same before it counts as done typing out
+var TypingSettle = 300 * time.Millisecond
+
+// OptionGap is how many pixels apart two words on the line of a choice have to be to count as separate options
+var OptionGap = 30
+
+// HashMinArea is how many pixels an object has to cover for its perceptual hash to be made
+var HashMinArea = 400
+
+// HashMaxDistance is how many bits the perceptual hashes of two sprites can differ by for them to be the same sprite
+var HashMaxDistance = 10
+
+// NavigateSettle is how long the cursor of a menu has to move after a key is pressed before the key is pressed again
+var NavigateSettle = 200 * time.Millisecond
+
+// NavigateExpiry is how long after a key is pressed in a menu that finding the cursor in the same place stops counting
+// as the key not working, as the menu has most likely been left and come back to. It should be a few NavigateSettles
+var NavigateExpiry = time.Second
+
+// NavigateRetries is how many times a key is pressed again when the cursor of a menu doesn't move before giving up
+var NavigateRetries = 3
+
+// InputLatency is how long the game is assumed to take to react to a key press until it has been measured
+var InputLatency = 100 * time.Millisecond
+
+// AttackTimeout is how long the attackPeg has to stop for after attacking before the attack is given up on
+var AttackTimeout = time.Second
+
+// HeartSpeed is how many pixels a second the heart is assumed to move at while an arrow key is held, until it has been
+// measured while dodging. This is a guess of 4 pixels a frame, which hasn't been checked against the game
+var HeartSpeed = 120.0
+
+// DodgeStep is how many pixels apart the places in the fightBox that the heart could dodge to are
+var DodgeStep = 10
+
+// DodgeMargin is how many pixels inside of the fightBox's bounds the heart can dodge to, which skips its border
+var DodgeMargin = 6
+
+// DodgeDeadzone is how many pixels off the heart can be from where it is dodging to before it is moved
+var DodgeDeadzone = 3
+
+// DodgeColor is the color that the place the heart is dodging to is outlined with
+var DodgeColor = color.RGBA{0, 255, 0, 255}
+
+// DarkRoomBrightness is the average brightness from 0 to 255 below which a frame outside of battles is from a dark room,
+// such as in Waterfall, and is thresholded with the dark pipeline
+var DarkRoomBrightness = 30.0
+
+// GroupMergeOverlap is how much an object found by a recognizer group's pipeline has to overlap an unrecognized object
+// from the main pipeline, from 0 to 1, for the two to be the same object
+var GroupMergeOverlap = 0.7
+
+// SelectionTimeout is how long an option that a strategy wants picked from a submenu waits for the submenu to show it
+var SelectionTimeout = 5 * time.Second
@@ -0,0 +1,66 @@
+// Package phash makes perceptual hashes of sprites, which stay about the same when a sprite is moved, slightly
+// animated or cut out a little differently, so sprites can be told apart without exact templates
+package phash
+
+import (
+	"image"
+	"math/bits"
+)
+
+// The hash compares each of the 8 rows of cells with the cell to its right, so it has 9 columns
+const (
+	columns = 9
+	rows    = 8
+)
+
+// Hash gets the difference hash of the part of the image inside of bounds.
+// Each bit says if a cell of the shrunk sprite is brighter than the cell to its right
+func Hash(img *image.RGBA, bounds image.Rectangle) uint64 {
+	bounds = bounds.Intersect(img.Bounds())
+	if bounds.Dx() < columns || bounds.Dy() < rows {
+		return 0
+	}
+
+	var cells [rows][columns]float64
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns; col++ {
+			cell := image.Rect(
+				bounds.Min.X+col*bounds.Dx()/columns, bounds.Min.Y+row*bounds.Dy()/rows,
+				bounds.Min.X+(col+1)*bounds.Dx()/columns, bounds.Min.Y+(row+1)*bounds.Dy()/rows,
+			)
+			cells[row][col] = brightness(img, cell)
+		}
+	}
+
+	var hash uint64
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns-1; col++ {
+			hash <<= 1
+			if cells[row][col] > cells[row][col+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// Distance counts how many bits two hashes differ by. Hashes of the same sprite are usually within a few bits
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Gets the average brightness of the pixels inside of a rectangle
+func brightness(img *image.RGBA, rect image.Rectangle) float64 {
+	var sum int
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			col := img.RGBAAt(x, y)
+			sum += int(col.R) + int(col.G) + int(col.B)
+		}
+	}
+	area := rect.Dx() * rect.Dy()
+	if area == 0 {
+		return 0
+	}
+	return float64(sum) / float64(area)
+}
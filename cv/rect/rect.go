@@ -5,6 +5,7 @@ import (
 	"image"
 	"image/color"
 
+	"gitlab.com/256/Underbot/cv/num"
 	"gitlab.com/256/Underbot/cv/object"
 )
 
@@ -19,11 +20,22 @@ func CenterColor(tmpImg image.Image) (color.Color, error) {
 	return img.At(centerPoint.X, centerPoint.Y), nil
 }
 
+// EdgeColor gets the average color of the points of a contour, which is the color of the object's outline
+func EdgeColor(img *image.RGBA, contour []image.Point) color.Color {
+	if len(contour) == 0 {
+		return color.RGBA{}
+	}
+	var r, g, b int
+	for _, point := range contour {
+		col := img.RGBAAt(point.X, point.Y)
+		r, g, b = r+int(col.R), g+int(col.G), b+int(col.B)
+	}
+	return color.RGBA{uint8(r / len(contour)), uint8(g / len(contour)), uint8(b / len(contour)), 255}
+}
+
 // RectangleCenter finds the point in the middle of a rectangle
 func RectangleCenter(rect image.Rectangle) (point image.Point) {
-	point.X = (rect.Min.X + rect.Max.X) / 2
-	point.Y = (rect.Min.Y + rect.Max.Y) / 2
-	return point
+	return num.Center(rect)
 }
 
 // DrawObject is a wrapper for the DrawRectangle function for objects
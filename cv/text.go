@@ -0,0 +1,61 @@
+package cv
+
+import (
+	"image"
+	"image/color"
+
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+// The text read from the text boxes of the last processed frame
+var texts []ocr.Text
+
+// GetTexts returns the text read from every text box in the last processed frame
+func GetTexts() []ocr.Text {
+	return texts
+}
+
+// Reads the text inside of every recognized text box, and the labels of the battle buttons.
+// This has to be done before anything is drawn on the frame, as the debugging rectangles would cover the text
+func readTexts(img *image.RGBA) {
+	texts = nil
+	for _, obj := range RecognizedObjects {
+		var lit ocr.Lit
+		switch {
+		case isTextBox(obj):
+			// Both white and yellow text is read, as enemies that can be spared have yellow names
+			lit = ocr.Either(ocr.Bright(params.TextBrightness), ocr.Yellow(params.TextBrightness))
+		case obj.RecogObj.Type.Is(object.RecMap["battleOption"]):
+			// The labels of the buttons are orange, or yellow while highlighted
+			lit = ocr.Either(orange, ocr.Yellow(params.TextBrightness))
+		default:
+			continue
+		}
+		bounds := obj.Bounds.Inset(params.TextInset)
+		font, lines, words := ocr.ReadBest(img, bounds, ocr.Fonts, lit)
+		texts = append(texts, ocr.Text{
+			Box:    obj.RecogObj.Type.Name,
+			Bounds: bounds,
+			Font:   font.Name,
+			Lines:  lines,
+			Words:  words,
+		})
+	}
+}
+
+// Treats the orange of the battle buttons that aren't highlighted as text
+func orange(col color.RGBA) bool {
+	return col.R >= params.TextBrightness && col.G >= 100 && col.G < 200 && col.B < 100
+}
+
+// Determines if an object was recognized as one of the boxes that text is shown in
+func isTextBox(obj object.Object) bool {
+	for _, box := range object.TextBoxes {
+		if obj.RecogObj.Type.Is(box) {
+			return true
+		}
+	}
+	return false
+}
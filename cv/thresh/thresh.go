@@ -0,0 +1,260 @@
+// Package thresh turns the colored frames into the black and white Mats that objects are found in
+package thresh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+	"gocv.io/x/gocv"
+)
+
+// Mats that are reused between frames so that they don't have to be reallocated every frame
+var (
+	grayMat    = gocv.NewMat() // The colorless version of the source Mat
+	stepMat    = gocv.NewMat() // The result of a single step inside of a method
+	combineMat = gocv.NewMat() // The result of a single method inside of a pipeline
+)
+
+// Method is a single way of thresholding a BGR Mat into a black and white Mat
+type Method interface {
+	Name() string                      // A short description of the method for the debugging window
+	Apply(src gocv.Mat, dst *gocv.Mat) // Places the thresholded version of src into dst
+}
+
+// Fixed is a global binary threshold on the grayscale image. Anything brighter than Level becomes white
+type Fixed struct {
+	Level float32
+}
+
+// Name describes the method
+func (f Fixed) Name() string {
+	return fmt.Sprintf("fixed %v", f.Level)
+}
+
+// Apply thresholds src into dst
+func (f Fixed) Apply(src gocv.Mat, dst *gocv.Mat) {
+	gocv.CvtColor(src, &grayMat, gocv.ColorBGRToGray)
+	gocv.Threshold(grayMat, dst, f.Level, 255, gocv.ThresholdBinary)
+}
+
+// Otsu is a global binary threshold whose level is picked every frame from the grayscale histogram
+type Otsu struct{}
+
+// Name describes the method
+func (o Otsu) Name() string {
+	return "otsu"
+}
+
+// Apply thresholds src into dst
+func (o Otsu) Apply(src gocv.Mat, dst *gocv.Mat) {
+	gocv.CvtColor(src, &grayMat, gocv.ColorBGRToGray)
+	gocv.Threshold(grayMat, dst, 0, 255, gocv.ThresholdBinary+gocv.ThresholdOtsu)
+}
+
+// Adaptive compares each pixel with the mean of the BlockSize x BlockSize pixels around it,
+// which keeps dim sprites in dark rooms from blending into the background.
+// Offset should be negative so that flat areas (like the black background) stay black
+type Adaptive struct {
+	BlockSize int // Must be odd
+	Offset    float32
+}
+
+// Name describes the method
+func (a Adaptive) Name() string {
+	return fmt.Sprintf("adaptive %v/%v", a.BlockSize, a.Offset)
+}
+
+// Apply thresholds src into dst
+func (a Adaptive) Apply(src gocv.Mat, dst *gocv.Mat) {
+	gocv.CvtColor(src, &grayMat, gocv.ColorBGRToGray)
+	gocv.AdaptiveThreshold(grayMat, dst, 255, gocv.AdaptiveThresholdMean, gocv.ThresholdBinary, a.BlockSize, a.Offset)
+}
+
+// ChannelMask thresholds the blue, green and red channels separately and combines them,
+// so that sprites which are dark in grayscale but strong in one color (such as dark blue or dark red) still stand out.
+// A level of 0 or less skips that channel
+type ChannelMask struct {
+	Blue  float32
+	Green float32
+	Red   float32
+}
+
+// Name describes the method
+func (c ChannelMask) Name() string {
+	return fmt.Sprintf("channels b%v g%v r%v", c.Blue, c.Green, c.Red)
+}
+
+// Apply thresholds src into dst
+func (c ChannelMask) Apply(src gocv.Mat, dst *gocv.Mat) {
+	channels := gocv.Split(src)
+	defer func() {
+		for _, channel := range channels {
+			err := channel.Close()
+			if err != nil {
+				panic(errors.Wrap(err, "failed to close the channel Mat"))
+			}
+		}
+	}()
+
+	// Start out with a completely black Mat, as nothing is brighter than 255
+	gocv.CvtColor(src, &grayMat, gocv.ColorBGRToGray)
+	gocv.Threshold(grayMat, dst, 255, 255, gocv.ThresholdBinary)
+
+	// Split gives the channels in BGR order
+	for i, level := range []float32{c.Blue, c.Green, c.Red} {
+		if level <= 0 || i >= len(channels) {
+			continue
+		}
+		gocv.Threshold(channels[i], &stepMat, level, 255, gocv.ThresholdBinary)
+		gocv.BitwiseOr(*dst, stepMat, dst)
+	}
+}
+
+// Pipeline is a named list of methods whose results are combined,
+// so anything picked up by any of the methods becomes an object
+type Pipeline struct {
+	Name    string
+	Methods []Method
+}
+
+// Apply runs every method of the pipeline on src and places the combined result in dst
+func (p Pipeline) Apply(src gocv.Mat, dst *gocv.Mat) error {
+	if len(p.Methods) == 0 {
+		return errors.New(fmt.Sprintf("the %s pipeline has no methods", p.Name))
+	}
+	for i, method := range p.Methods {
+		if i == 0 {
+			method.Apply(src, dst)
+			continue
+		}
+		method.Apply(src, &combineMat)
+		gocv.BitwiseOr(*dst, combineMat, dst)
+	}
+	return nil
+}
+
+// String describes the pipeline and its methods for the debugging window
+func (p Pipeline) String() string {
+	names := make([]string, 0, len(p.Methods))
+	for _, method := range p.Methods {
+		names = append(names, method.Name())
+	}
+	return fmt.Sprintf("%s (%s)", p.Name, strings.Join(names, " + "))
+}
+
+// Group makes a set of recognizers be found with their own pipeline instead of the one for the current area
+type Group struct {
+	Name        string
+	Recognizers []object.RecognizableObject
+	Pipeline    Pipeline
+}
+
+// Areas holds the pipelines that can be used for the different areas of the game. The first one is the default
+var Areas = []Pipeline{
+	// The original threshold, which works for the brighter areas such as the Ruins
+	{"default", []Method{Fixed{50}}},
+	// Darker areas such as Waterfall, where dark blue and dark red sprites blend into the background
+	{"dark", []Method{Fixed{50}, ChannelMask{Blue: 40, Green: 0, Red: 40}}},
+	{"otsu", []Method{Otsu{}}},
+	{"adaptive", []Method{Adaptive{BlockSize: 15, Offset: -10}}},
+}
+
+// Groups holds the recognizer groups that use their own pipeline.
+// Recognizers in a group are only checked against the objects that the group's pipeline finds
+var Groups = []Group{
+	// Each heart is strong in one channel, which keeps it apart from the white attacks that touch it
+	{"hearts", object.Hearts, Pipeline{"hearts", []Method{ChannelMask{Blue: 128, Green: 128, Red: 128}}}},
+	// Frisk's side and back are a dark red-brown that is darker than the default threshold in grayscale
+	{"frisk dark", []object.RecognizableObject{object.RecMap["friskSideBody"], object.RecMap["friskBack"]},
+		Pipeline{"red", []Method{ChannelMask{Red: 40}}}},
+}
+
+// StateAreas maps the states of the AI to the area whose pipeline they use.
+// Battles are always white on black, while outside of them the area depends on the room, which is judged by its brightness
+var StateAreas = map[string]string{
+	"battleMenu": "default",
+	"inBattle":   "default",
+	"attackGoal": "default",
+}
+
+// The index of the pipeline in Areas currently being used
+var area int
+
+// Manual is whether the pipeline was picked by hand, which stops it from being switched automatically
+var Manual bool
+
+// Current returns the pipeline for the current area of the game
+func Current() Pipeline {
+	return Areas[area]
+}
+
+// Choose switches to the pipeline for the state of the AI and the average brightness of the frame from 0 to 255,
+// unless the pipeline was picked by hand
+func Choose(state string, brightness float64) {
+	if Manual {
+		return
+	}
+	name, ok := StateAreas[state]
+	if !ok {
+		name = "default"
+		if brightness < params.DarkRoomBrightness {
+			name = "dark"
+		}
+	}
+	err := SetArea(name)
+	if err != nil {
+		// Areas was changed without updating the names above, so stick with the default
+		area = 0
+	}
+}
+
+// SetArea switches to the pipeline in Areas with the name given
+func SetArea(name string) error {
+	for i, pipeline := range Areas {
+		if pipeline.Name == name {
+			area = i
+			return nil
+		}
+	}
+	return errors.New(fmt.Sprintf("there is no pipeline for the area %s", name))
+}
+
+// NextArea picks the next pipeline in Areas by hand. After the last one, the pipeline goes back to being picked automatically
+func NextArea() {
+	if !Manual {
+		Manual = true
+		area = 0
+		return
+	}
+	area++
+	if area >= len(Areas) {
+		Manual = false
+		area = 0
+	}
+}
+
+// Ungrouped returns the recognizers that aren't part of any group, and should use the current area's pipeline
+func Ungrouped(recognizers []object.RecognizableObject) []object.RecognizableObject {
+	var ungrouped []object.RecognizableObject
+	for _, recogObj := range recognizers {
+		if !grouped(recogObj) {
+			ungrouped = append(ungrouped, recogObj)
+		}
+	}
+	return ungrouped
+}
+
+// Determines if a recognizer is part of a group
+func grouped(recogObj object.RecognizableObject) bool {
+	for _, group := range Groups {
+		for _, member := range group.Recognizers {
+			if member.Is(recogObj) {
+				return true
+			}
+		}
+	}
+	return false
+}
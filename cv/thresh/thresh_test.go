@@ -0,0 +1,56 @@
+package thresh
+
+import (
+	"testing"
+
+	"gocv.io/x/gocv"
+)
+
+// TestChoose checks that the pipeline follows the state and the brightness of the frame until it is picked by hand
+func TestChoose(t *testing.T) {
+	defer func() { area, Manual = 0, false }()
+	cases := []struct {
+		state      string
+		brightness float64
+		want       string
+	}{
+		{"outsideBattle", 100, "default"},
+		{"outsideBattle", 5, "dark"},
+		{"inBattle", 5, "default"},
+		{"dialogue", 5, "dark"},
+	}
+	for _, c := range cases {
+		Choose(c.state, c.brightness)
+		if Current().Name != c.want {
+			t.Errorf("Choose(%q, %v) picked %s, want %s", c.state, c.brightness, Current().Name, c.want)
+		}
+	}
+
+	// Picking by hand goes through every pipeline, and then back to picking automatically
+	for i := range Areas {
+		NextArea()
+		if !Manual || Current().Name != Areas[i].Name {
+			t.Fatalf("picking by hand %v times gave %s, want %s", i+1, Current().Name, Areas[i].Name)
+		}
+		Choose("outsideBattle", 5)
+		if Current().Name != Areas[i].Name {
+			t.Errorf("Choose replaced %s picked by hand with %s", Areas[i].Name, Current().Name)
+		}
+	}
+	NextArea()
+	if Manual {
+		t.Error("picking past the last pipeline didn't go back to picking automatically")
+	}
+}
+
+// TestEmptyPipeline checks that a pipeline without methods is an error rather than leaving the old result in dst
+func TestEmptyPipeline(t *testing.T) {
+	src := gocv.NewMat()
+	dst := gocv.NewMat()
+	defer src.Close()
+	defer dst.Close()
+	err := Pipeline{Name: "empty"}.Apply(src, &dst)
+	if err == nil {
+		t.Error("applying a pipeline with no methods didn't fail")
+	}
+}
@@ -0,0 +1,286 @@
+// Package track follows objects across frames so that they keep the same ID and their movement can be measured
+package track
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+	"time"
+
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/rect"
+)
+
+// How many of the most recent samples are used to estimate velocity
+const velocitySamples = 5
+
+// Tracker keeps the tracks of the objects seen in past frames
+type Tracker struct {
+	tracks []*track
+	nextID int
+}
+
+// A single object followed across frames
+type track struct {
+	id      int
+	desc    descriptor // What the object looked like the last time it was seen
+	history []object.Sample
+	vel     object.Velocity
+	missed  int // How many frames in a row the object wasn't found
+}
+
+// What an object looks like, which tells apart objects that are near each other
+type descriptor struct {
+	typ   string // The name of what the object was recognized as, or empty if it wasn't
+	size  image.Point
+	color color.RGBA // The color in the center of the object
+	edge  color.RGBA // The average color of the object's outline
+}
+
+// Describes what an object looks like
+func describe(obj object.Object) descriptor {
+	return descriptor{
+		typ:   typeName(obj),
+		size:  obj.Bounds.Size(),
+		color: toRGBA(obj.Color),
+		edge:  toRGBA(obj.EdgeColor),
+	}
+}
+
+// Scores how alike two descriptors are, from 0 to 1. Objects recognized as different things, or with very different sizes,
+// can't be the same object. Otherwise the score drops as the colors differ, by up to params.TrackColorWeight
+func (d descriptor) similarity(other descriptor) float64 {
+	if d.typ != other.typ || !similarSize(d.size, other.size) {
+		return 0
+	}
+	colorDiff := (colorDistance(d.color, other.color) + colorDistance(d.edge, other.edge)) / 2
+	return 1 - params.TrackColorWeight*colorDiff
+}
+
+// A possible match between a track and an object in the current frame
+type candidate struct {
+	track  int
+	object int
+	score  float64
+}
+
+// NewTracker creates a Tracker with no tracks
+func NewTracker() *Tracker {
+	return &Tracker{nextID: 1}
+}
+
+// Update matches the objects of a new frame to the existing tracks and fills in their Motion.
+// Tracks outside of the regions processed aren't counted as missed, as nobody looked for them
+func (t *Tracker) Update(objs []object.Object, regions []image.Rectangle, at time.Time) {
+	trackUsed := make([]bool, len(t.tracks))
+	objUsed := make([]bool, len(objs))
+
+	// Match the most similar pairs first
+	candidates := t.candidates(objs, at)
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	for _, cand := range candidates {
+		if trackUsed[cand.track] || objUsed[cand.object] {
+			continue
+		}
+		trackUsed[cand.track] = true
+		objUsed[cand.object] = true
+		t.tracks[cand.track].add(&objs[cand.object], at)
+	}
+
+	// Forget the tracks that haven't been seen for too long
+	var kept []*track
+	for i, tr := range t.tracks {
+		if !trackUsed[i] && looked(tr.history[len(tr.history)-1].Bounds, regions) {
+			tr.missed++
+		}
+		if tr.missed <= params.TrackMaxMissed {
+			kept = append(kept, tr)
+		}
+	}
+	t.tracks = kept
+
+	// Everything left over is a new object
+	for i := range objs {
+		if objUsed[i] {
+			continue
+		}
+		tr := &track{id: t.nextID}
+		t.nextID++
+		tr.add(&objs[i], at)
+		t.tracks = append(t.tracks, tr)
+	}
+}
+
+// Scores the pairings of tracks and objects that could be the same thing.
+// The objects are put in a grid by their centers, so each track only looks at the objects near its predicted position
+func (t *Tracker) candidates(objs []object.Object, at time.Time) []candidate {
+	cell := int(params.TrackMaxJump)
+	if cell < 1 {
+		cell = 1
+	}
+	grid := make(map[image.Point][]int)
+	for j, obj := range objs {
+		key := cellOf(rect.RectangleCenter(obj.Bounds), cell)
+		grid[key] = append(grid[key], j)
+	}
+
+	var candidates []candidate
+	for i, tr := range t.tracks {
+		predicted := tr.predict(at)
+		// Large objects can overlap the prediction with their centers further away than the jump allowed
+		reach := int(params.TrackMaxJump) + (predicted.Dx()+predicted.Dy())/2
+		from := cellOf(rect.RectangleCenter(predicted).Sub(image.Pt(reach, reach)), cell)
+		to := cellOf(rect.RectangleCenter(predicted).Add(image.Pt(reach, reach)), cell)
+		for y := from.Y; y <= to.Y; y++ {
+			for x := from.X; x <= to.X; x++ {
+				for _, j := range grid[image.Pt(x, y)] {
+					score := tr.score(predicted, objs[j])
+					if score > 0 {
+						candidates = append(candidates, candidate{track: i, object: j, score: score})
+					}
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// Gets the cell of a grid with cells of the size given that a point is in
+func cellOf(point image.Point, size int) image.Point {
+	return image.Point{floorDiv(point.X, size), floorDiv(point.Y, size)}
+}
+
+// Divides, rounding down for negative numbers too
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+// Scores how likely the object is to be the one being tracked, 0 meaning it can't be.
+// Overlapping the predicted position always scores higher than just being near it,
+// and the score is then weighed by how alike the object looks to the tracked one
+func (tr *track) score(predicted image.Rectangle, obj object.Object) float64 {
+	similarity := tr.desc.similarity(describe(obj))
+	if similarity <= 0 {
+		return 0
+	}
+	if overlap := iou(predicted, obj.Bounds); overlap > 0 {
+		return (1 + overlap) * similarity
+	}
+	dist := distance(rect.RectangleCenter(predicted), rect.RectangleCenter(obj.Bounds))
+	if dist > params.TrackMaxJump {
+		return 0
+	}
+	return (1 - dist/(params.TrackMaxJump+1)) * similarity
+}
+
+// Adds the object's position to the track and fills in the object's Motion
+func (tr *track) add(obj *object.Object, at time.Time) {
+	tr.missed = 0
+	tr.desc = describe(*obj)
+	tr.history = append(tr.history, object.Sample{Bounds: obj.Bounds, At: at})
+	if len(tr.history) > params.TrackHistory {
+		tr.history = tr.history[len(tr.history)-params.TrackHistory:]
+	}
+	tr.vel = velocity(tr.history)
+
+	// The object keeps the same ID for as long as it is tracked.
+	// The history is copied, as the object is handed to other goroutines while the track keeps changing
+	obj.ID = tr.id
+	obj.Motion = object.Motion{
+		TrackID:  tr.id,
+		History:  append([]object.Sample{}, tr.history...),
+		Velocity: tr.vel,
+	}
+}
+
+// Estimates where the tracked object will be at the time given
+func (tr *track) predict(at time.Time) image.Rectangle {
+	last := tr.history[len(tr.history)-1]
+	motion := object.Motion{History: []object.Sample{last}, Velocity: tr.vel}
+	return motion.Predict(at.Sub(last.At))
+}
+
+// Estimates the velocity from the most recent samples
+func velocity(history []object.Sample) object.Velocity {
+	if len(history) < 2 {
+		return object.Velocity{}
+	}
+	first := history[0]
+	if len(history) > velocitySamples {
+		first = history[len(history)-velocitySamples]
+	}
+	last := history[len(history)-1]
+	seconds := last.At.Sub(first.At).Seconds()
+	if seconds <= 0 {
+		return object.Velocity{}
+	}
+	from := rect.RectangleCenter(first.Bounds)
+	to := rect.RectangleCenter(last.Bounds)
+	return object.Velocity{
+		X: float64(to.X-from.X) / seconds,
+		Y: float64(to.Y-from.Y) / seconds,
+	}
+}
+
+// Gets the name of what the object was recognized as, or an empty string if it wasn't
+func typeName(obj object.Object) string {
+	if !obj.Recognized {
+		return ""
+	}
+	return obj.RecogObj.Type.Name
+}
+
+// Determines if two sizes are close enough for the objects to be the same, allowing for half the size of difference
+func similarSize(a, b image.Point) bool {
+	return math.Abs(float64(a.X-b.X)) <= float64(a.X)/2+float64(params.Leniance) &&
+		math.Abs(float64(a.Y-b.Y)) <= float64(a.Y)/2+float64(params.Leniance)
+}
+
+// Gets the intersection over union of two rectangles, which is 1 when they are the same and 0 when they don't overlap
+func iou(a, b image.Rectangle) float64 {
+	inter := a.Intersect(b)
+	if inter.Empty() {
+		return 0
+	}
+	interArea := float64(inter.Dx() * inter.Dy())
+	unionArea := float64(a.Dx()*a.Dy()+b.Dx()*b.Dy()) - interArea
+	return interArea / unionArea
+}
+
+// Converts a color to RGBA, which is black for a missing color
+func toRGBA(col color.Color) color.RGBA {
+	if col == nil {
+		return color.RGBA{}
+	}
+	return color.RGBAModel.Convert(col).(color.RGBA)
+}
+
+// Gets how far apart two colors are, from 0 when they are the same to 1 when they are black and white
+func colorDistance(a, b color.RGBA) float64 {
+	dr := float64(a.R) - float64(b.R)
+	dg := float64(a.G) - float64(b.G)
+	db := float64(a.B) - float64(b.B)
+	return math.Sqrt(dr*dr+dg*dg+db*db) / math.Sqrt(3*255*255)
+}
+
+// Gets the distance between two points
+func distance(a, b image.Point) float64 {
+	return math.Hypot(float64(a.X-b.X), float64(a.Y-b.Y))
+}
+
+// Determines if a rectangle is inside of any of the regions that were processed
+func looked(bounds image.Rectangle, regions []image.Rectangle) bool {
+	for _, region := range regions {
+		if bounds.Overlaps(region) {
+			return true
+		}
+	}
+	return false
+}
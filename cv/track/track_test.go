@@ -0,0 +1,106 @@
+package track
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"gitlab.com/256/Underbot/cv/object"
+)
+
+// Makes an unrecognized 10x10 object of a single color at the position given
+func square(x, y int, col color.RGBA) object.Object {
+	return object.Object{Bounds: image.Rect(x, y, x+10, y+10), ID: 1, Color: col, EdgeColor: col}
+}
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	blue  = color.RGBA{0, 0, 255, 255}
+	start = time.Date(2018, 7, 31, 0, 0, 0, 0, time.UTC)
+	frame = 33 * time.Millisecond
+	whole = []image.Rectangle{image.Rect(-100, -100, 400, 400)}
+)
+
+// TestCrossing checks that two objects moving through each other keep their IDs
+func TestCrossing(t *testing.T) {
+	tracker := NewTracker()
+	var leftID, rightID int
+	for i := 0; i <= 10; i++ {
+		objs := []object.Object{square(10*i, 50, red), square(100-10*i, 50, blue)}
+		tracker.Update(objs, whole, start.Add(time.Duration(i)*frame))
+		if i == 0 {
+			leftID, rightID = objs[0].ID, objs[1].ID
+			if leftID == rightID {
+				t.Fatalf("both objects got the ID %v", leftID)
+			}
+			continue
+		}
+		if objs[0].ID != leftID || objs[1].ID != rightID {
+			t.Fatalf("frame %v: IDs changed from %v and %v to %v and %v", i, leftID, rightID, objs[0].ID, objs[1].ID)
+		}
+	}
+}
+
+// TestVelocity checks that the velocity is measured in pixels per second
+func TestVelocity(t *testing.T) {
+	tracker := NewTracker()
+	var obj object.Object
+	for i := 0; i < 5; i++ {
+		obj = square(3*i, 20-2*i, red)
+		objs := []object.Object{obj}
+		tracker.Update(objs, whole, start.Add(time.Duration(i)*100*time.Millisecond))
+		obj = objs[0]
+	}
+	if obj.Motion.Velocity.X < 29 || obj.Motion.Velocity.X > 31 || obj.Motion.Velocity.Y < -21 || obj.Motion.Velocity.Y > -19 {
+		t.Errorf("got a velocity of %+v, want about {X:30 Y:-20}", obj.Motion.Velocity)
+	}
+	if len(obj.Motion.History) != 5 {
+		t.Errorf("got %v samples of history, want 5", len(obj.Motion.History))
+	}
+}
+
+// TestForget checks that an object unseen for too long comes back with a new ID,
+// while one outside of the regions looked at keeps its ID
+func TestForget(t *testing.T) {
+	tracker := NewTracker()
+	objs := []object.Object{square(0, 0, red)}
+	tracker.Update(objs, whole, start)
+	first := objs[0].ID
+
+	elsewhere := []image.Rectangle{image.Rect(200, 200, 300, 300)}
+	for i := 1; i <= 20; i++ {
+		tracker.Update(nil, elsewhere, start.Add(time.Duration(i)*frame))
+	}
+	objs = []object.Object{square(0, 0, red)}
+	tracker.Update(objs, whole, start.Add(21*frame))
+	if objs[0].ID != first {
+		t.Errorf("an object outside of the regions looked at changed ID from %v to %v", first, objs[0].ID)
+	}
+
+	for i := 22; i <= 40; i++ {
+		tracker.Update(nil, whole, start.Add(time.Duration(i)*frame))
+	}
+	objs = []object.Object{square(0, 0, red)}
+	tracker.Update(objs, whole, start.Add(41*frame))
+	if objs[0].ID == first {
+		t.Errorf("an object that wasn't seen for 19 frames kept its ID %v", first)
+	}
+}
+
+// TestDescriptor checks that an object recognized as something else isn't matched to a track, even in the same place
+func TestDescriptor(t *testing.T) {
+	tracker := NewTracker()
+	heart := square(0, 0, red)
+	heart.Recognized = true
+	heart.RecogObj = object.RecognizedObject{Type: object.RecMap["redHeart"]}
+	objs := []object.Object{heart}
+	tracker.Update(objs, whole, start)
+	heartID := objs[0].ID
+
+	objs = []object.Object{square(0, 0, red)}
+	tracker.Update(objs, whole, start.Add(frame))
+	if objs[0].ID == heartID {
+		t.Errorf("an unrecognized object took over the track %v of the heart", heartID)
+	}
+}
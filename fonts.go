@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/png" // Screenshots are saved as PNGs
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/params"
+)
+
+// Holds where the glyphs learned from screenshots are kept, and the samples to learn them from if the user wants to
+var fontDir = flag.String("fonts", "fonts", "directory of the glyphs learned from screenshots, which are used over the built in ones")
+var learn = flag.String("learn", "", "learn glyphs from the screenshots listed in this JSON file into the fonts directory, and exit")
+
+// A screenshot of text that is already known, which the glyphs of a font are cut out of
+type fontSample struct {
+	Image  string `json:"image"`  // The path of the screenshot, relative to the file listing the samples
+	Font   string `json:"font"`   // The name of the font the text is in
+	Region [4]int `json:"region"` // The left, top, right and bottom of the text in the screenshot
+	Text   string `json:"text"`   // What the text says, with a newline between each line
+}
+
+// HandleFonts loads the glyphs learned from screenshots over the built in ones. Flags have to be parsed first
+func HandleFonts() error {
+	for _, name := range []string{ocr.HUD.Name, ocr.Dialogue.Name, ocr.Sans.Name, ocr.Papyrus.Name} {
+		glyphs, err := loadGlyphs(name)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to load the glyphs of the %s font", name))
+		}
+		if len(glyphs) == 0 {
+			continue
+		}
+		font, _ := ocr.Named(name)
+		err = ocr.Use(font.Merge(glyphs))
+		if err != nil {
+			return errors.Wrap(err, "failed to use the learned glyphs")
+		}
+	}
+	return nil
+}
+
+// LearnFonts cuts the glyphs out of every sample listed in the file, adding them to the fonts directory
+func LearnFonts(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open the samples")
+	}
+	var samples []fontSample
+	err = json.NewDecoder(file).Decode(&samples)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode the samples")
+	}
+	err = file.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to close the samples")
+	}
+
+	for _, sample := range samples {
+		if !filepath.IsAbs(sample.Image) {
+			sample.Image = filepath.Join(filepath.Dir(path), sample.Image)
+		}
+		err = learnSample(sample)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to learn from %s", sample.Image))
+		}
+	}
+	return nil
+}
+
+// Learns the glyphs of a single sample and saves them along with the ones learned before
+func learnSample(sample fontSample) error {
+	font, ok := ocr.Named(sample.Font)
+	if !ok {
+		return errors.New(fmt.Sprintf("there is no font named %s", sample.Font))
+	}
+	img, err := loadScreenshot(sample.Image)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the screenshot")
+	}
+	region := image.Rect(sample.Region[0], sample.Region[1], sample.Region[2], sample.Region[3])
+	learned, err := ocr.Learn(img, region, font, sample.Text, ocr.Bright(params.TextBrightness))
+	if err != nil {
+		return errors.Wrap(err, "failed to cut out the glyphs")
+	}
+
+	known, err := loadGlyphs(font.Name)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the glyphs learned before")
+	}
+	glyphs := ocr.Font{Glyphs: known}.Merge(learned).Glyphs
+
+	err = os.MkdirAll(*fontDir, 0755)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the fonts directory")
+	}
+	file, err := os.Create(filepath.Join(*fontDir, font.Name+".json"))
+	if err != nil {
+		return errors.Wrap(err, "failed to create the glyphs file")
+	}
+	err = ocr.Save(file, glyphs)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the glyphs")
+	}
+	err = file.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to close the glyphs file")
+	}
+	fmt.Printf("Learned %v glyphs of the %s font from %s\n", len(learned), font.Name, sample.Image)
+	return nil
+}
+
+// Loads the glyphs learned for a font, or none if nothing has been learned for it yet
+func loadGlyphs(name string) ([]ocr.Glyph, error) {
+	file, err := os.Open(filepath.Join(*fontDir, name+".json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the glyphs file")
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			panic(errors.Wrap(err, "failed to close the glyphs file"))
+		}
+	}()
+	return ocr.Load(file)
+}
+
+// Loads a screenshot as an RGBA image
+func loadScreenshot(path string) (*image.RGBA, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the screenshot")
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			panic(errors.Wrap(err, "failed to close the screenshot"))
+		}
+	}()
+	decoded, _, err := image.Decode(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode the screenshot")
+	}
+	img := image.NewRGBA(decoded.Bounds())
+	draw.Draw(img, img.Bounds(), decoded, decoded.Bounds().Min, draw.Src)
+	return img, nil
+}
@@ -5,16 +5,17 @@ import (
 	"image"
 	"runtime/pprof"
 	"strings"
+	"sync/atomic"
 
 	"github.com/hajimehoshi/ebiten/inpututil"
 
 	"gitlab.com/256/Underbot/ai"
 	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/thresh"
 	"gitlab.com/256/Underbot/sys"
 	impl "gitlab.com/256/Underbot/sys/Impl"
 
-	"gitlab.com/256/Underbot/cv"
-
 	"gitlab.com/256/Underbot/winmanage"
 
 	"github.com/pkg/errors"
@@ -44,36 +45,66 @@ var keyForwards = []ebiten.Key{
 	ebiten.KeyRight,
 }
 
-// Draws the screen cast of the Undertale window to the screen with the CV information added
-func update(screen *ebiten.Image) error {
-	if !ebiten.IsRunningSlowly() {
-		prints = 0
+// The most recent frame to make it through the pipeline, and the ebiten.Image made from it
+var latest *frame
+var latestImage *ebiten.Image
 
-		img, err := screenCast()
-		if err != nil {
-			return errors.Wrap(err, "failed to get the image from the window")
-		}
+// Draws the latest frame from the pipeline to the screen with the CV information added.
+// This is the display stage of the pipeline, so nothing here holds up the AI
+func update(screen *ebiten.Image) error {
+	select {
+	case err := <-stageErrors:
+		return errors.Wrap(err, "a stage of the pipeline failed")
+	default:
+	}
 
-		// Create ebiten.Image from the image holding the undertale window with CV drawings on it
-		window, err := ebiten.NewImageFromImage(img, ebiten.FilterDefault)
-		if err != nil {
-			return errors.Wrap(err, "failed to make image from image")
-		}
+	err := takeLatest()
+	if err != nil {
+		return errors.Wrap(err, "failed to take the latest frame")
+	}
+	// Nothing has made it through the pipeline yet
+	if latest == nil {
+		return nil
+	}
 
+	prints = 0
+	if !ebiten.IsRunningSlowly() {
 		// Draw the image to the screen
-		err = screen.DrawImage(window, &ebiten.DrawImageOptions{})
+		err = screen.DrawImage(latestImage, &ebiten.DrawImageOptions{})
 		if err != nil {
 			return errors.Wrap(err, "failed to draw the final image to the screen")
 		}
 
-		err = printDebugInfo(screen)
+		err = printDebugInfo(screen, latest)
 		if err != nil {
 			return errors.Wrap(err, "failed to print debugging information to the screen")
 		}
-		err = handleInput(screen)
+	}
+	err = handleInput(screen, latest)
+	if err != nil {
+		return errors.Wrap(err, "failed to handle user input")
+	}
+	return nil
+}
+
+// Replaces the latest frame if the AI has finished with a newer one
+func takeLatest() error {
+	select {
+	case f := <-decided:
+		// Create ebiten.Image from the image holding the undertale window with CV drawings on it
+		window, err := ebiten.NewImageFromImage(f.img, ebiten.FilterDefault)
 		if err != nil {
-			return errors.Wrap(err, "failed to handle user input")
+			return errors.Wrap(err, "failed to make image from image")
+		}
+		if latestImage != nil {
+			err = latestImage.Dispose()
+			if err != nil {
+				return errors.Wrap(err, "failed to dispose of the previous image")
+			}
 		}
+		latest = f
+		latestImage = window
+	default:
 	}
 	return nil
 }
@@ -88,23 +119,50 @@ func debugPrint(image *ebiten.Image, str string) error {
 	return nil
 }
 
-// Prints various important details to the screen for debugging
-func printDebugInfo(screen *ebiten.Image) error {
+// Prints various important details about a frame to the screen for debugging
+func printDebugInfo(screen *ebiten.Image, f *frame) error {
 	err := debugPrint(screen, fmt.Sprintf("FPS: %v", ebiten.CurrentFPS()))
 	if err != nil {
 		return errors.Wrap(err, "failed to print FPS")
 	}
-	if ai.Disabled {
+	err = debugPrint(screen, fmt.Sprintf("Dropped frames: capture %v, CV %v, AI %v",
+		atomic.LoadInt64(&droppedCapture), atomic.LoadInt64(&droppedCV), atomic.LoadInt64(&droppedAI)))
+	if err != nil {
+		return errors.Wrap(err, "failed to print dropped frames")
+	}
+	err = debugPrint(screen, fmt.Sprintf("Duplicate frames: %v", atomic.LoadInt64(&duplicateFrames)))
+	if err != nil {
+		return errors.Wrap(err, "failed to print duplicate frames")
+	}
+	if f.aiDisabled {
 		err := debugPrint(screen, "State: DISABLED")
 		if err != nil {
 			return errors.Wrap(err, "failed to print the disabled state")
 		}
 	} else {
-		err := debugPrint(screen, fmt.Sprintf("State: %s", ai.CurrentState.Name))
+		err := debugPrint(screen, fmt.Sprintf("State: %s", f.state))
 		if err != nil {
 			return errors.Wrap(err, "failed to print the current state")
 		}
 	}
+	err = debugPrint(screen, fmt.Sprintf("Threshold: %s", f.threshold))
+	if err != nil {
+		return errors.Wrap(err, "failed to print the threshold pipeline")
+	}
+	if f.focused {
+		err = debugPrint(screen, "Processing: regions of interest")
+	} else {
+		err = debugPrint(screen, "Processing: whole frame")
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to print the processed regions")
+	}
+	for _, group := range thresh.Groups {
+		err = debugPrint(screen, fmt.Sprintf("Threshold for %s: %s", group.Name, group.Pipeline))
+		if err != nil {
+			return errors.Wrap(err, "failed to print the threshold pipeline of a group")
+		}
+	}
 	err = debugPrint(screen, "Click over an object to get information.")
 	if err != nil {
 		return errors.Wrap(err, "failed to print instructions")
@@ -125,11 +183,15 @@ func printDebugInfo(screen *ebiten.Image) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to print instructions")
 	}
+	err = debugPrint(screen, "Press T to pick the threshold pipeline by hand, past the last one to go back to automatic")
+	if err != nil {
+		return errors.Wrap(err, "failed to print instructions")
+	}
 	err = debugPrint(screen, "Other recognized keys will be forwarded to the game")
 	if err != nil {
 		return errors.Wrap(err, "failed to print instructions")
 	}
-	if ai.Disabled {
+	if f.aiDisabled {
 		err = debugPrint(screen, "The AI is currently DISABLED")
 		if err != nil {
 			return errors.Wrap(err, "failed to print AI status")
@@ -141,21 +203,123 @@ func printDebugInfo(screen *ebiten.Image) error {
 		}
 	}
 
-	for _, recogObj := range cv.GetRecognizedObjects() {
-		err = debugPrint(screen, fmt.Sprintf("Recognized %s in object %v", recogObj.RecogObj.Type.Name, recogObj.ID))
+	for _, recogObj := range f.recognized {
+		name := recogObj.RecogObj.Type.Name
+		if recogObj.RecogObj.Type.Animated() {
+			name = fmt.Sprintf("%s frame %v/%v", name, recogObj.RecogObj.Type.Frame+1, recogObj.RecogObj.Type.Frames)
+		}
+		err = debugPrint(screen, fmt.Sprintf("Recognized %s in object %v (%.2f)", name, recogObj.ID, recogObj.RecogObj.Score))
 		if err != nil {
 			return errors.Wrap(err, "failed to print recognized object")
 		}
 	}
+	for _, entity := range f.entities {
+		err = debugPrint(screen, fmt.Sprintf("Entity %s facing %s, walking: %v (%.2f)",
+			entity.Name, entity.Facing, entity.Walking, entity.Confidence))
+		if err != nil {
+			return errors.Wrap(err, "failed to print entity")
+		}
+	}
+	if len(f.projectiles) > 0 {
+		err = debugPrint(screen, fmt.Sprintf("Projectiles: %v (%s), reaction: %s",
+			len(f.projectiles), attackColors(f.projectiles), f.reaction))
+		if err != nil {
+			return errors.Wrap(err, "failed to print projectiles")
+		}
+	}
+	if f.stats.Valid {
+		err = debugPrint(screen, fmt.Sprintf("LV %v, HP %v/%v, low: %v",
+			f.stats.LV, f.stats.HP, f.stats.MaxHP, f.stats.Low(params.LowHP)))
+		if err != nil {
+			return errors.Wrap(err, "failed to print the stats")
+		}
+	}
+	for _, text := range f.texts {
+		err = debugPrint(screen, fmt.Sprintf("%s (%s): %s", text.Box, text.Font, text))
+		if err != nil {
+			return errors.Wrap(err, "failed to print text")
+		}
+	}
+	if f.prompt != nil {
+		err = debugPrint(screen, fmt.Sprintf("Prompt: %s", f.prompt))
+		if err != nil {
+			return errors.Wrap(err, "failed to print the prompt")
+		}
+	}
+	if f.decision != "" {
+		err = debugPrint(screen, fmt.Sprintf("Last answer: %s", f.decision))
+		if err != nil {
+			return errors.Wrap(err, "failed to print the last answer")
+		}
+	}
+	if f.attack != "" {
+		err = debugPrint(screen, fmt.Sprintf("Last attack: %s", f.attack))
+		if err != nil {
+			return errors.Wrap(err, "failed to print the last attack")
+		}
+	}
+	if f.menu.String() != "" {
+		err = debugPrint(screen, fmt.Sprintf("Battle menu: %s", f.menu))
+		if err != nil {
+			return errors.Wrap(err, "failed to print the battle menu")
+		}
+	}
+	if f.submenu != nil {
+		err = debugPrint(screen, fmt.Sprintf("Submenu: %s", f.submenu))
+		if err != nil {
+			return errors.Wrap(err, "failed to print the submenu")
+		}
+	}
+	if len(f.encounter.Enemies) > 0 {
+		err = debugPrint(screen, fmt.Sprintf("Fighting %v enemies: %s, spareable: %v",
+			len(f.encounter.Enemies), strings.Join(f.encounter.Names(), ", "), f.encounter.Spareable()))
+		if err != nil {
+			return errors.Wrap(err, "failed to print the encounter")
+		}
+	}
+	if f.hits > 0 {
+		err = debugPrint(screen, fmt.Sprintf("Hits taken this battle: %v", f.hits))
+		if err != nil {
+			return errors.Wrap(err, "failed to print hits taken")
+		}
+	}
+	for _, obj := range f.objects {
+		if obj.Ambiguous(params.AmbiguityMargin) {
+			err = debugPrint(screen, fmt.Sprintf("Object %v is ambiguous: %s", obj.ID, candidateList(obj)))
+			if err != nil {
+				return errors.Wrap(err, "failed to print ambiguous object")
+			}
+		}
+	}
 	return nil
 }
 
-func handleInput(screen *ebiten.Image) error {
+// Counts the projectiles of each attack color
+func attackColors(projectiles []ai.Projectile) string {
+	counts := make(map[ai.AttackColor]int)
+	for _, proj := range projectiles {
+		counts[proj.Color]++
+	}
+	return fmt.Sprintf("%v white, %v blue, %v orange, %v other",
+		counts[ai.AttackWhite], counts[ai.AttackBlue], counts[ai.AttackOrange], counts[ai.AttackOther])
+}
+
+// Lists the candidates of an object along with their scores
+func candidateList(obj object.Object) string {
+	names := make([]string, 0, len(obj.Candidates))
+	for _, cand := range obj.Candidates {
+		names = append(names, fmt.Sprintf("%s %.2f", cand.Type.Name, cand.Score))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Handles the mouse and keyboard input to the debugging window using the objects from a frame
+func handleInput(screen *ebiten.Image, f *frame) error {
 	// Shows the parent objects of the location where the pointer is and debugging information about those objects
 	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
 		x, y := ebiten.CursorPosition()
 		cursorPoint := image.Point{x, y}
-		parents := allParents(cursorPoint, cv.GetObjects())
+		parents := allParents(cursorPoint, f.objects)
 		for _, parent := range parents {
 			rect := parent.Bounds
 			if parent.Recognized {
@@ -172,6 +336,13 @@ func handleInput(screen *ebiten.Image) error {
 					return errors.Wrap(err, "failed to print parent object")
 				}
 			}
+			if parent.Motion.Tracked() {
+				err := debugPrint(screen, fmt.Sprintf("  Track %v moving %.0f, %.0f px/s", parent.Motion.TrackID,
+					parent.Motion.Velocity.X, parent.Motion.Velocity.Y))
+				if err != nil {
+					return errors.Wrap(err, "failed to print the track of the parent object")
+				}
+			}
 		}
 	}
 
@@ -187,9 +358,11 @@ func handleInput(screen *ebiten.Image) error {
 			return errors.Wrap(err, "failed to resume the game")
 		}
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyA) {
-		ai.Disabled = !ai.Disabled
+		control(aiControls, func() { ai.Disabled = !ai.Disabled })
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyG) {
-		ai.GridShow = !ai.GridShow
+		control(aiControls, func() { ai.GridShow = !ai.GridShow })
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyT) {
+		control(cvControls, thresh.NextArea)
 	}
 
 	for _, key := range keyForwards {
@@ -207,33 +380,18 @@ func handleInput(screen *ebiten.Image) error {
 	return nil
 }
 
-// Gets all the objects that the 'point' is within
-func allParents(point image.Point, objs []object.Object) []object.Object {
-	var parents = []object.Object{}
-	for _, obj := range objs {
-		if point.In(obj.Bounds) {
-			parents = append(parents, obj)
-		}
-	}
-	return parents
-}
-
-// Gets the image from the window, and runs the ProcessImage function on it
-func screenCast() (image.Image, error) {
-	image, err := mainWindow.GetImage()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to get the image from the window")
-	}
-	err = cv.ProcessImage(&image, mainWindow)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to process the image")
-	}
-	return &image, nil
+// Gets all the objects that the 'point' is within by walking down the contour tree
+func allParents(point image.Point, objs []object.Object) []*object.Object {
+	return object.At(point, objs)
 }
 
 /*
  Handles main execution.
  -cpuprofile and -memprofile can be used for profiling to a file
+ -transcripts sets where the dialogue transcripts are saved, and -search searches them
+ -answers sets the file of the rules for answering prompts
+ -sprites sets the file that the enemy sprites learned are kept in between sessions
+ -fonts sets where the glyphs learned from screenshots are kept, and -learn learns them from a list of screenshots
 */
 func main() {
 	// Profiling
@@ -249,6 +407,52 @@ func main() {
 		panic(errors.Wrap(err, "failed to profile the application"))
 	}
 
+	// Searching the transcripts of past sessions doesn't need the game
+	if *search != "" {
+		err = SearchTranscripts(*search)
+		if err != nil {
+			panic(errors.Wrap(err, "failed to search the transcripts"))
+		}
+		return
+	}
+	// Learning glyphs from screenshots doesn't need the game either
+	if *learn != "" {
+		err = LearnFonts(*learn)
+		if err != nil {
+			panic(errors.Wrap(err, "failed to learn the fonts"))
+		}
+		return
+	}
+	err = HandleFonts()
+	if err != nil {
+		panic(errors.Wrap(err, "failed to load the learned fonts"))
+	}
+	err = HandleAnswers()
+	if err != nil {
+		panic(errors.Wrap(err, "failed to load the rules for answering prompts"))
+	}
+	err = HandleSprites()
+	if err != nil {
+		panic(errors.Wrap(err, "failed to load the enemy sprites"))
+	}
+	defer func() {
+		err := SaveSprites()
+		if err != nil {
+			panic(errors.Wrap(err, "failed to save the enemy sprites"))
+		}
+	}()
+
+	err = HandleTranscript()
+	if err != nil {
+		panic(errors.Wrap(err, "failed to start the transcript"))
+	}
+	defer func() {
+		err := CloseTranscript()
+		if err != nil {
+			panic(errors.Wrap(err, "failed to close the transcript"))
+		}
+	}()
+
 	serv, err := impl.NewServer()
 	if err != nil {
 		panic(errors.Wrap(err, "failed to find/get a server for use"))
@@ -260,6 +464,15 @@ func main() {
 		panic(errors.Wrap(err, "failed to get the window"))
 	}
 
+	// The stages are stopped before the sprites are saved and the transcript is closed, as the AI adds to them
+	startPipeline(mainWindow)
+	defer func() {
+		err := stopPipeline(mainWindow)
+		if err != nil {
+			panic(errors.Wrap(err, "failed to stop the pipeline"))
+		}
+	}()
+
 	ebiten.SetRunnableInBackground(true)
 	width, height, err := mainWindow.WxH()
 	if err != nil {
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"gitlab.com/256/Underbot/ai"
+	"gitlab.com/256/Underbot/cv"
+	"gitlab.com/256/Underbot/cv/hud"
+	"gitlab.com/256/Underbot/cv/object"
+	"gitlab.com/256/Underbot/cv/ocr"
+	"gitlab.com/256/Underbot/cv/params"
+	"gitlab.com/256/Underbot/cv/thresh"
+	"gitlab.com/256/Underbot/sys"
+)
+
+// frame is a single capture of the window, along with what was found in it, as it moves through the pipeline.
+// Each stage only touches a frame until it passes it on to the next stage
+type frame struct {
+	img         *image.RGBA
+	captured    time.Time
+	objects     []object.Object
+	recognized  []object.Object
+	threshold   string // The threshold pipeline used to find the objects
+	focused     bool   // Whether only the regions of interest were processed
+	state       string // The state the AI decided the game was in
+	entities    []object.Entity
+	projectiles []ai.Projectile
+	reaction    ai.Reaction
+	hits        int       // How many hits the heart has taken in the current battle
+	stats       hud.Stats // The stats read from the battle HUD
+	texts       []ocr.Text
+	prompt      *ai.Prompt // The choice shown in the dialogue box, or nil if there isn't one
+	decision    string     // The last decision made about a prompt
+	attack      string     // The last attack made on the FIGHT bar
+	encounter   ai.Encounter
+	menu        ai.BattleMenu
+	submenu     *ai.Submenu // The submenu opened from the battle menu, or nil if there isn't one
+	aiDisabled  bool
+}
+
+// The channels connecting the stages.
+// Each holds a single frame, which gets replaced if the next stage hasn't taken it yet, so no stage works on stale frames
+var (
+	captured  = make(chan *frame, 1) // Capture -> CV
+	processed = make(chan *frame, 1) // CV -> AI
+	decided   = make(chan *frame, 1) // AI -> Display
+)
+
+// Holds the first error from any of the background stages so the display can stop the program
+var stageErrors = make(chan error, 1)
+
+// Closed to stop the background stages, which are counted in stages until they have returned
+var (
+	done   = make(chan struct{})
+	stages sync.WaitGroup
+)
+
+// Changes to settings requested by the debugging window.
+// They are run by the stage that reads the setting, in between frames
+var (
+	cvControls = make(chan func(), 10)
+	aiControls = make(chan func(), 10)
+)
+
+// How many frames each stage has thrown away because the next stage was still busy
+var droppedCapture, droppedCV, droppedAI int64
+
+// How many captured frames were the same as the one before them
+var duplicateFrames int64
+
+// Whether the AI's current state needs to see duplicate frames too. Only touched by the CV stage
+var aiWantsDuplicates bool
+
+// Starts the capture, CV and AI stages. The display stage is run by ebiten through update()
+func startPipeline(win sys.Window) {
+	stages.Add(3)
+	go captureStage(win)
+	go cvStage()
+	go aiStage(win)
+}
+
+// Stops the background stages and waits for them to return, so that nothing uses what they share with the rest of
+// the program afterwards. Keys the AI was holding down are then released, as the game would keep them held
+func stopPipeline(win sys.Window) error {
+	close(done)
+	stages.Wait()
+	err := ai.ReleaseKeys(win)
+	if err != nil {
+		return errors.Wrap(err, "failed to release the held keys")
+	}
+	return nil
+}
+
+// Takes screenshots of the window as fast as possible
+func captureStage(win sys.Window) {
+	defer stages.Done()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		img, err := win.GetImage()
+		if err != nil {
+			fail(errors.Wrap(err, "failed to get the image from the window"))
+			return
+		}
+		sendLatest(captured, &frame{img: &img, captured: time.Now()}, &droppedCapture)
+	}
+}
+
+// Finds and recognizes the objects in each captured frame
+func cvStage() {
+	defer stages.Done()
+	for {
+		var f *frame
+		select {
+		case <-done:
+			return
+		case f = <-captured:
+		}
+		runControls(cvControls)
+		if cv.IsDuplicate(f.img) {
+			atomic.AddInt64(&duplicateFrames, 1)
+			if !params.TickDuplicates && !aiWantsDuplicates {
+				continue
+			}
+			// Nothing changed, so reuse the objects from the last frame. The frame gets its own copies of them,
+			// as the last frame can still be in use by the AI or the display
+			cv.Redraw(f.img)
+			f.objects, f.recognized = cv.Snapshot()
+		} else {
+			err := cv.ProcessImage(f.img, f.captured)
+			if err != nil {
+				fail(errors.Wrap(err, "failed to process the image"))
+				return
+			}
+			f.objects = cv.GetObjects()
+			f.recognized = cv.GetRecognizedObjects()
+		}
+		f.threshold = thresh.Current().String()
+		if thresh.Manual {
+			f.threshold += ", picked by hand"
+		}
+		f.focused = cv.Focused()
+		f.stats = cv.GetStats()
+		f.texts = cv.GetTexts()
+		sendLatest(processed, f, &droppedCV)
+	}
+}
+
+// Lets the AI act upon each processed frame
+func aiStage(win sys.Window) {
+	defer stages.Done()
+	for {
+		var f *frame
+		select {
+		case <-done:
+			return
+		case f = <-processed:
+		}
+		runControls(aiControls)
+		ai.Stats = f.stats
+		ai.Texts = f.texts
+		err := ai.Handle(f.objects, f.recognized, win, f.img)
+		if err != nil {
+			fail(errors.Wrap(err, "ai failed to act upon the objects"))
+			err = ai.ReleaseKeys(win)
+			if err != nil {
+				fmt.Println("Failed to release the held keys:", err)
+			}
+			return
+		}
+		f.state = ai.CurrentState.Name
+		f.aiDisabled = ai.Disabled
+		f.entities = ai.Entities
+		f.projectiles = ai.Projectiles
+		f.reaction = ai.CurrentReaction
+		f.hits = ai.HitsTaken
+		f.prompt = ai.CurrentPrompt
+		f.encounter = ai.CurrentEncounter
+		f.menu = ai.CurrentMenu
+		f.submenu = ai.CurrentSubmenu
+		if len(ai.Decisions) > 0 {
+			f.decision = ai.Decisions[len(ai.Decisions)-1].String()
+		}
+		if len(ai.Attacks) > 0 {
+			f.attack = ai.Attacks[len(ai.Attacks)-1].String()
+		}
+
+		// Have the CV only look at what the current state cares about in the next frames
+		regions, recognizers := ai.FocusRegions(f.recognized)
+		duplicates := ai.CurrentState.Duplicates() && !ai.Disabled
+		state := ai.CurrentState.Name
+		control(cvControls, func() {
+			cv.SetFocus(regions, recognizers)
+			cv.SetState(state)
+			aiWantsDuplicates = duplicates
+		})
+
+		sendLatest(decided, f, &droppedAI)
+	}
+}
+
+// Sends a frame to the next stage. If the last frame sent is still waiting, it is thrown away and counted in dropped
+func sendLatest(ch chan *frame, f *frame, dropped *int64) {
+	for {
+		select {
+		case ch <- f:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+			atomic.AddInt64(dropped, 1)
+		default:
+		}
+	}
+}
+
+// Reports an error from a background stage, keeping only the first one
+func fail(err error) {
+	select {
+	case stageErrors <- err:
+	default:
+	}
+}
+
+// Queues a change to a setting for the stage reading it. If the queue is full the change is ignored
+func control(ch chan func(), fn func()) {
+	select {
+	case ch <- fn:
+	default:
+	}
+}
+
+// Runs the queued setting changes without waiting for new ones
+func runControls(ch chan func()) {
+	for {
+		select {
+		case fn := <-ch:
+			fn()
+		default:
+			return
+		}
+	}
+}
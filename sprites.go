@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/ai"
+)
+
+// Holds where the enemy sprites learned during past sessions are kept
+var spritesPath = flag.String("sprites", "sprites.json", "file to keep the enemy sprites learned from session to session in, or empty to not keep them")
+
+// HandleSprites loads the enemy sprites learned during past sessions. Flags have to be parsed first
+func HandleSprites() error {
+	if *spritesPath == "" {
+		return nil
+	}
+	file, err := os.Open(*spritesPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "failed to open the sprites")
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			panic(errors.Wrap(err, "failed to close the sprites"))
+		}
+	}()
+	return ai.LoadSprites(file)
+}
+
+// SaveSprites keeps the enemy sprites known so far for the next session
+func SaveSprites() error {
+	if *spritesPath == "" {
+		return nil
+	}
+	file, err := os.Create(*spritesPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the sprites file")
+	}
+	err = ai.SaveSprites(file)
+	if err != nil {
+		return errors.Wrap(err, "failed to save the sprites")
+	}
+	err = file.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to close the sprites file")
+	}
+	return nil
+}
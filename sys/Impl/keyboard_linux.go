@@ -105,3 +105,29 @@ func (win window) justPress(key string) error {
 	}
 	return nil
 }
+
+// Hold pushes a key down on whatever window is active, leaving it down until Release is used
+func (win window) Hold(key string) error {
+	lower := strings.ToLower(key)
+	if keycodes[lower] == nil {
+		return errors.New("the key given was not one included in the keycodes map")
+	}
+	err := keycodes[lower].Press()
+	if err != nil {
+		return errors.Wrap(err, "failed to push the key")
+	}
+	return nil
+}
+
+// Release lets go of a key that was held with Hold
+func (win window) Release(key string) error {
+	lower := strings.ToLower(key)
+	if keycodes[lower] == nil {
+		return errors.New("the key given was not one included in the keycodes map")
+	}
+	err := keycodes[lower].Release()
+	if err != nil {
+		return errors.Wrap(err, "failed to release the key")
+	}
+	return nil
+}
@@ -23,6 +23,8 @@ type Window interface {
 	Pause() error                   // Should pause the game
 	Resume() error                  // Should resume the game
 	Press(string) error             // Emulates a key press
+	Hold(string) error              // Pushes a key down until it is released
+	Release(string) error           // Lets go of a key that was held
 	WxH() (int, int, error)         // Gets the width and height of the window
 	// An ID tied to the underlying window in some way
 	// For example, for checking if two window instances are referring to the same window
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"gitlab.com/256/Underbot/ai"
+	"gitlab.com/256/Underbot/ai/transcript"
+)
+
+// Holds where the transcripts are saved, and what to search them for if the user only wants to search them
+var transcriptDir = flag.String("transcripts", "transcripts", "directory to save the dialogue transcript of each session in, or empty to not save them")
+var search = flag.String("search", "", "search the saved transcripts for dialogue containing this text, and exit")
+
+var transcriptFile *os.File
+
+// HandleTranscript starts the transcript for this session. Flags have to be parsed first
+func HandleTranscript() error {
+	if *transcriptDir == "" {
+		return nil
+	}
+	err := os.MkdirAll(*transcriptDir, 0755)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the transcript directory")
+	}
+	name := time.Now().Format("2006-01-02_15-04-05") + ".jsonl"
+	transcriptFile, err = os.Create(filepath.Join(*transcriptDir, name))
+	if err != nil {
+		return errors.Wrap(err, "failed to create the transcript file")
+	}
+	ai.Transcript = transcript.New(transcriptFile)
+	return nil
+}
+
+// CloseTranscript writes out the lines still on screen and closes the transcript file
+func CloseTranscript() error {
+	if transcriptFile == nil {
+		return nil
+	}
+	err := ai.Transcript.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to finish the transcript")
+	}
+	err = transcriptFile.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to close the transcript file")
+	}
+	return nil
+}
+
+// SearchTranscripts prints the lines in every saved transcript that contain the text given
+func SearchTranscripts(query string) error {
+	paths, err := filepath.Glob(filepath.Join(*transcriptDir, "*.jsonl"))
+	if err != nil {
+		return errors.Wrap(err, "failed to list the transcripts")
+	}
+	for _, path := range paths {
+		err = searchTranscript(path, query)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to search %s", path))
+		}
+	}
+	return nil
+}
+
+// Prints the lines in a single transcript that contain the text given
+func searchTranscript(path string, query string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open the transcript")
+	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			panic(errors.Wrap(err, "failed to close the transcript"))
+		}
+	}()
+
+	entries, err := transcript.Search(file, query)
+	if err != nil {
+		return errors.Wrap(err, "failed to search the transcript")
+	}
+	for _, entry := range entries {
+		fmt.Printf("%s: %s\n", filepath.Base(path), entry)
+	}
+	return nil
+}